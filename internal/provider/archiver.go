@@ -6,18 +6,120 @@ package archive
 import (
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
 )
 
 type ArchiveDirOpts struct {
+	TargetPrefix              string
+	Includes                  []string
 	Excludes                  []string
+	ExcludesSyntax            string
 	ExcludeSymlinkDirectories bool
+	// SymlinkMode is the SymlinkMode* handling of symbolic links, which defaults to following them.
+	SymlinkMode              string
+	NodePruneDevDependencies bool
+	// IgnoreFiles are the names of the ignore files, such as .gitignore, read from each walked directory.
+	IgnoreFiles []string
+	// IgnoreFilesUsed receives the paths of the ignore files read during the walk, when set.
+	IgnoreFilesUsed *[]string
+	// ExcludeLargerThan excludes files larger than this many bytes when positive, and ExcludeOlderThan files last
+	// modified before it when set.
+	ExcludeLargerThan int64
+	ExcludeOlderThan  time.Time
+	// ExcludeFileTypes are the FileType* kinds of files to exclude.
+	ExcludeFileTypes []string
+	// StripComponents leading directories are removed from the paths of the files, relative to the directory, and
+	// files with fewer directories are skipped.
+	StripComponents int
+	// Flatten drops the directories of the files once StripComponents is applied, archiving them by their base name.
+	Flatten bool
+	// PathTransforms rename the files of the directory once StripComponents and Flatten are applied, before
+	// TargetPrefix is added.
+	PathTransforms []PathTransform
+	// OnCollision is the OnCollision* policy for files renamed to the same name, which defaults to an error.
+	OnCollision string
+	// IncludeDirectories archives the directories below the directory, including empty ones, as directory entries.
+	IncludeDirectories bool
+
+	gitignore   *gitignoreMatcher
+	ignoreFiles *gitignoreMatcher
+	nodeModules *nodeModulesFilter
+}
+
+const (
+	SymlinkModeFollow   = "follow"
+	SymlinkModePreserve = "preserve"
+	SymlinkModeSkip     = "skip"
+)
+
+const (
+	OnCollisionError = "error"
+	OnCollisionFirst = "first"
+	OnCollisionLast  = "last"
+)
+
+const (
+	FileTypeSocket = "socket"
+	FileTypeFIFO   = "fifo"
+	FileTypeDevice = "device"
+	FileTypeEmpty  = "empty"
+)
+
+// ArchiveDirSource is a single directory archived by ArchiveDirs.
+type ArchiveDirSource struct {
+	Path string
+	Opts ArchiveDirOpts
+}
+
+// ArchiveEntry is a single file written to an archive by ArchiveEntries.
+type ArchiveEntry struct {
+	// Name is the slash separated path of the file inside the archive.
+	Name string
+	// Content is written when SourcePath is empty.
+	Content []byte
+	// SourcePath is the file to read the content from, described by SourceInfo.
+	SourcePath string
+	SourceInfo os.FileInfo
+	// Mode overrides the defaults of the archive when ModeSet is true, which tells a mode of 0000 apart from no mode.
+	Mode    os.FileMode
+	ModeSet bool
+	// ModTime overrides the defaults of the archive when set.
+	ModTime time.Time
+	// LinkTarget makes the entry a symbolic link to it, instead of a file.
+	LinkTarget string
+	// HardLinkTarget makes the entry a hard link to the entry of that name archived before it in tar archives.
+	HardLinkTarget string
+	// Owner and Group own the file in tar archives.
+	Owner ArchiveOwner
+	Group ArchiveOwner
+	// Xattrs are the extended attributes of the file by name, recorded by tar archives.
+	Xattrs map[string]string
+}
+
+// setMode overrides the mode of the entry.
+func (e *ArchiveEntry) setMode(mode os.FileMode) {
+	e.Mode = mode
+	e.ModeSet = true
+}
+
+// isDir reports whether the entry is a directory.
+func (e ArchiveEntry) isDir() bool {
+	return e.SourceInfo != nil && e.SourceInfo.IsDir() && e.LinkTarget == ""
 }
 
 type Archiver interface {
 	ArchiveContent(content []byte, infilename string) error
 	ArchiveFile(infilename string) error
 	ArchiveDir(indirname string, opts ArchiveDirOpts) error
+	ArchiveDirs(dirs []ArchiveDirSource) error
 	ArchiveMultiple(content map[string][]byte) error
+	ArchiveEntries(entries []ArchiveEntry) error
 	SetOutputFileMode(outputFileMode string)
 }
 
@@ -58,3 +160,547 @@ func assertValidDir(indirname string) error {
 
 	return nil
 }
+
+// fileEntry returns the entry for a single file, archived under its base name.
+func fileEntry(infilename string) (ArchiveEntry, error) {
+	fi, err := assertValidFile(infilename)
+	if err != nil {
+		return ArchiveEntry{}, err
+	}
+
+	return ArchiveEntry{
+		Name:       filepath.ToSlash(fi.Name()),
+		SourcePath: infilename,
+		SourceInfo: fi,
+	}, nil
+}
+
+// contentEntries returns an entry for each file in content, sorted by name.
+func contentEntries(content map[string][]byte) []ArchiveEntry {
+	// Ensure files are processed in the same order so hashes don't change
+	keys := make([]string, 0, len(content))
+	for k := range content {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	entries := make([]ArchiveEntry, len(keys))
+	for i, filename := range keys {
+		entries[i] = ArchiveEntry{
+			Name:    filepath.ToSlash(filename),
+			Content: content[filename],
+		}
+	}
+
+	return entries
+}
+
+func assertNotEmpty(entries []ArchiveEntry) error {
+	// Return an error if an empty archive would be generated.
+	if len(entries) == 0 {
+		return fmt.Errorf("archive has not been created as it would be empty")
+	}
+
+	return nil
+}
+
+// walkDir returns the files of indirname which are not excluded by opts, and its directories when IncludeDirectories
+// is set, in the order they are walked.
+func walkDir(indirname string, opts ArchiveDirOpts) ([]ArchiveEntry, error) {
+	err := assertValidDir(indirname)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.TargetPrefix, err = cleanArchivePrefix(opts.TargetPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target prefix: %s", err)
+	}
+
+	// ensure inclusions and exclusions are OS compatible paths
+	includes := make([]string, len(opts.Includes))
+	for i := range opts.Includes {
+		includes[i] = filepath.FromSlash(opts.Includes[i])
+	}
+	opts.Includes = includes
+
+	switch opts.ExcludesSyntax {
+	case "", ExcludesSyntaxGlob:
+	case ExcludesSyntaxGitignore:
+		opts.gitignore, err = newGitignoreMatcher(opts.Excludes)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported excludes syntax: %s", opts.ExcludesSyntax)
+	}
+
+	switch opts.SymlinkMode {
+	case "", SymlinkModeFollow, SymlinkModePreserve, SymlinkModeSkip:
+	default:
+		return nil, fmt.Errorf("unsupported symlink mode: %s", opts.SymlinkMode)
+	}
+
+	excludes := make([]string, len(opts.Excludes))
+	for i := range opts.Excludes {
+		excludes[i] = filepath.FromSlash(opts.Excludes[i])
+	}
+	opts.Excludes = excludes
+
+	if len(opts.IgnoreFiles) > 0 {
+		opts.ignoreFiles = &gitignoreMatcher{}
+	}
+
+	if opts.NodePruneDevDependencies {
+		opts.nodeModules, err = newNodeModulesFilter(indirname)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var files []ArchiveEntry
+	if err := filepath.Walk(indirname, createWalkFunc("", indirname, opts, &files)); err != nil {
+		return nil, err
+	}
+
+	if opts.StripComponents > 0 || opts.Flatten || len(opts.PathTransforms) > 0 {
+		return resolvePathCollisions(files, opts.OnCollision)
+	}
+
+	return files, nil
+}
+
+func createWalkFunc(basePath, indirname string, opts ArchiveDirOpts, files *[]ArchiveEntry) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("error encountered during file walk: %s", err)
+		}
+
+		relname, err := filepath.Rel(indirname, path)
+		if err != nil {
+			return fmt.Errorf("error relativizing file for archival: %s", err)
+		}
+
+		archivePath := filepath.Join(basePath, relname)
+
+		// Directories excluded by a pattern such as "dir/**" are still walked when a later negated pattern may
+		// re-include files inside them.
+		var isMatch, walksExcluded bool
+		if opts.gitignore != nil {
+			isMatch = opts.gitignore.excludes(archivePath, info.IsDir())
+			walksExcluded = isMatch && info.IsDir() && opts.gitignore.walksExcluded(archivePath)
+		} else {
+			isMatch, err = checkMatch(archivePath, opts.Excludes)
+			if err != nil {
+				return fmt.Errorf("error checking excludes matches: %w", err)
+			}
+		}
+
+		if !isMatch && opts.ignoreFiles != nil {
+			isMatch = opts.ignoreFiles.excludes(archivePath, info.IsDir())
+			walksExcluded = isMatch && info.IsDir() && opts.ignoreFiles.walksExcluded(archivePath)
+		}
+
+		if !isMatch && opts.nodeModules != nil {
+			isMatch = opts.nodeModules.excludes(archivePath, info.IsDir())
+		}
+
+		if info.IsDir() {
+			if isMatch && !walksExcluded {
+				return filepath.SkipDir
+			}
+
+			isIncluded, err := checkIncludeMatchBelow(archivePath, opts.Includes)
+			if err != nil {
+				return fmt.Errorf("error checking includes matches: %w", err)
+			}
+			if !isIncluded {
+				return filepath.SkipDir
+			}
+
+			if err := readIgnoreFiles(path, archivePath, opts); err != nil {
+				return err
+			}
+
+			if isMatch || !opts.IncludeDirectories || archivePath == "." {
+				return nil
+			}
+
+			// Only the directories matching the includes are archived, rather than every directory walked to find
+			// the files matching them.
+			isIncluded, err = checkIncludeMatch(archivePath, opts.Includes)
+			if err != nil {
+				return fmt.Errorf("error checking includes matches: %w", err)
+			}
+			if !isIncluded {
+				return nil
+			}
+
+			name, ok, err := archiveName(archivePath, true, opts)
+			if err != nil || !ok {
+				return err
+			}
+
+			*files = append(*files, ArchiveEntry{
+				Name:       name,
+				SourcePath: path,
+				SourceInfo: info,
+			})
+
+			return nil
+		}
+
+		if isMatch {
+			return nil
+		}
+
+		var preserveLink bool
+		if info.Mode()&os.ModeSymlink == os.ModeSymlink {
+			switch opts.SymlinkMode {
+			case SymlinkModeSkip:
+				return nil
+			case SymlinkModePreserve:
+				preserveLink = true
+			default:
+				realPath, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					return err
+				}
+
+				realInfo, err := os.Stat(realPath)
+				if err != nil {
+					return err
+				}
+
+				if realInfo.IsDir() {
+					if !opts.ExcludeSymlinkDirectories {
+						isIncluded, err := checkIncludeMatchBelow(archivePath, opts.Includes)
+						if err != nil {
+							return fmt.Errorf("error checking includes matches: %w", err)
+						}
+						if !isIncluded {
+							return nil
+						}
+						return filepath.Walk(realPath, createWalkFunc(archivePath, realPath, opts, files))
+					} else {
+						return filepath.SkipDir
+					}
+				}
+
+				info = realInfo
+			}
+		}
+
+		if checkFileInfoMatch(info, opts) {
+			return nil
+		}
+
+		isIncluded, err := checkIncludeMatch(archivePath, opts.Includes)
+		if err != nil {
+			return fmt.Errorf("error checking includes matches: %w", err)
+		}
+		if !isIncluded {
+			return nil
+		}
+
+		name, ok, err := archiveName(archivePath, false, opts)
+		if err != nil || !ok {
+			return err
+		}
+
+		// Links are read once their name in the archive is known, as strip_components, flatten and path_transform
+		// move them relative to their targets.
+		var linkTarget string
+		if preserveLink {
+			linkTarget, err = readSymlink(path, name)
+			if err != nil {
+				return err
+			}
+		}
+
+		*files = append(*files, ArchiveEntry{
+			Name:       name,
+			SourcePath: path,
+			SourceInfo: info,
+			LinkTarget: linkTarget,
+		})
+
+		return nil
+	}
+}
+
+// archiveName returns the slash separated name inside the archive of the file or directory archivePath, relative to
+// the walked directory, once StripComponents, Flatten, PathTransforms and TargetPrefix are applied. It returns false
+// when the file or directory is not archived, such as directories once flattened.
+func archiveName(archivePath string, isDir bool, opts ArchiveDirOpts) (string, bool, error) {
+	name := archivePath
+	if opts.StripComponents > 0 {
+		stripped, ok := stripComponents(filepath.ToSlash(name), opts.StripComponents)
+		if !ok {
+			return "", false, nil
+		}
+		name = filepath.FromSlash(stripped)
+	}
+
+	if opts.Flatten {
+		if isDir {
+			return "", false, nil
+		}
+		name = filepath.Base(name)
+	}
+
+	if len(opts.PathTransforms) > 0 && isDir {
+		transformed, ok, err := transformDirPath(filepath.ToSlash(name), opts.PathTransforms)
+		if err != nil || !ok {
+			return "", false, err
+		}
+		name = filepath.FromSlash(transformed)
+	} else if len(opts.PathTransforms) > 0 {
+		transformed, err := transformPath(filepath.ToSlash(name), opts.PathTransforms)
+		if err != nil {
+			return "", false, err
+		}
+		name = filepath.FromSlash(transformed)
+	}
+
+	return filepath.ToSlash(filepath.Join(opts.TargetPrefix, name)), true, nil
+}
+
+// readSymlink returns the slash separated target of the symbolic link at linkPath, archived as name, which must point
+// inside of the archive.
+func readSymlink(linkPath, name string) (string, error) {
+	target, err := os.Readlink(linkPath)
+	if err != nil {
+		return "", fmt.Errorf("error reading symbolic link: %s", err)
+	}
+
+	if err := checkLinkTarget(name, target); err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(target), nil
+}
+
+// checkLinkTarget reports symbolic links archived as name whose target is absolute or outside of the archive, as they
+// would point outside of the directory the archive is extracted to. Links must be checked once their name in the
+// archive is known, after strip_components, flatten, path_transform and target_prefix, as their targets are relative
+// to it.
+func checkLinkTarget(name, target string) error {
+	slashTarget := filepath.ToSlash(target)
+	resolved := path.Join(path.Dir(name), slashTarget)
+	if filepath.IsAbs(target) || path.IsAbs(slashTarget) || resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return fmt.Errorf("symbolic link %s points outside of the archive: %s", name, target)
+	}
+
+	return nil
+}
+
+// readIgnoreFiles adds the patterns of the ignore files found in the directory dirPath, archived as archivePath, to
+// the patterns applied to the files below it.
+func readIgnoreFiles(dirPath, archivePath string, opts ArchiveDirOpts) error {
+	if opts.ignoreFiles == nil {
+		return nil
+	}
+
+	base := filepath.ToSlash(archivePath)
+	if base == "." {
+		base = ""
+	}
+
+	for _, name := range opts.IgnoreFiles {
+		filename := filepath.Join(dirPath, name)
+
+		found, err := opts.ignoreFiles.addFile(base, filename)
+		if err != nil {
+			return err
+		}
+
+		if found && opts.IgnoreFilesUsed != nil {
+			*opts.IgnoreFilesUsed = append(*opts.IgnoreFilesUsed, filepath.ToSlash(filename))
+		}
+	}
+
+	return nil
+}
+
+// checkFileInfoMatch reports whether the file described by info is excluded by its size, modification time or type.
+func checkFileInfoMatch(info os.FileInfo, opts ArchiveDirOpts) bool {
+	if opts.ExcludeLargerThan > 0 && info.Size() > opts.ExcludeLargerThan {
+		return true
+	}
+
+	if !opts.ExcludeOlderThan.IsZero() && info.ModTime().Before(opts.ExcludeOlderThan) {
+		return true
+	}
+
+	mode := info.Mode()
+	for _, fileType := range opts.ExcludeFileTypes {
+		switch fileType {
+		case FileTypeSocket:
+			if mode&os.ModeSocket != 0 {
+				return true
+			}
+		case FileTypeFIFO:
+			if mode&os.ModeNamedPipe != 0 {
+				return true
+			}
+		case FileTypeDevice:
+			if mode&os.ModeDevice != 0 {
+				return true
+			}
+		case FileTypeEmpty:
+			if mode.IsRegular() && info.Size() == 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parseExcludeOlderThan returns the time files must be modified after to be archived, given either an RFC3339
+// timestamp or a duration before now.
+func parseExcludeOlderThan(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid exclude_older_than %q, must be an RFC3339 timestamp or a positive duration", value)
+	}
+
+	return now.Add(-d), nil
+}
+
+// checkIncludeMatch reports whether fileName, or one of the directories containing it, matches one of the includes.
+// Every file is included when there are no includes.
+func checkIncludeMatch(fileName string, includes []string) (bool, error) {
+	if len(includes) == 0 {
+		return true, nil
+	}
+
+	for name := fileName; name != "." && name != string(filepath.Separator); name = filepath.Dir(name) {
+		isMatch, err := checkMatch(name, includes)
+		if err != nil || isMatch {
+			return isMatch, err
+		}
+	}
+
+	return false, nil
+}
+
+// checkIncludeMatchBelow reports whether one of the includes matches dirName or could match a file below it, so
+// that directories which cannot contain any included file are not walked.
+func checkIncludeMatchBelow(dirName string, includes []string) (bool, error) {
+	if dirName == "." {
+		return true, nil
+	}
+
+	isMatch, err := checkIncludeMatch(dirName, includes)
+	if err != nil || isMatch {
+		return isMatch, err
+	}
+
+	dirSegments := strings.Split(dirName, string(filepath.Separator))
+	for _, include := range includes {
+		if include != "" && couldMatchBelow(dirSegments, strings.Split(include, string(filepath.Separator))) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// couldMatchBelow reports whether the pattern segments could match a path with the directory segments as a prefix.
+func couldMatchBelow(dirSegments, patternSegments []string) bool {
+	for i, segment := range dirSegments {
+		if i >= len(patternSegments) {
+			return false
+		}
+
+		if patternSegments[i] == "**" {
+			return true
+		}
+
+		isMatch, err := doublestar.Match(patternSegments[i], segment)
+		if err != nil {
+			// Keep walking, the pattern error is reported when matching the files below the directory.
+			return true
+		}
+		if !isMatch {
+			return false
+		}
+	}
+
+	return len(patternSegments) > len(dirSegments)
+}
+
+// walkDirs walks every directory and merges their files, sorted by name. Files from different directories which
+// would be archived with the same name are reported as an error.
+func walkDirs(dirs []ArchiveDirSource) ([]ArchiveEntry, error) {
+	var files entryList
+
+	for _, dir := range dirs {
+		dirFiles, err := walkDir(dir.Path, dir.Opts)
+		if err != nil {
+			return nil, err
+		}
+
+		files.add(dir.Path, dirFiles...)
+	}
+
+	if err := files.err("source directories"); err != nil {
+		return nil, err
+	}
+
+	// Ensure files are processed in the same order so hashes don't change
+	sort.Slice(files.entries, func(i, j int) bool {
+		return files.entries[i].Name < files.entries[j].Name
+	})
+
+	return files.entries, nil
+}
+
+// entryList collects the entries of several sources, keeping track of which source added each name so that files
+// archived under the same name by more than one source can be reported.
+type entryList struct {
+	entries   []ArchiveEntry
+	owners    map[string]string
+	dirs      map[string]bool
+	conflicts []string
+}
+
+func (l *entryList) add(source string, entries ...ArchiveEntry) {
+	if l.owners == nil {
+		l.owners = make(map[string]string)
+		l.dirs = make(map[string]bool)
+	}
+
+	for _, entry := range entries {
+		if owner, ok := l.owners[entry.Name]; ok {
+			// Directories are archived once, whichever sources contain them.
+			if entry.isDir() && l.dirs[entry.Name] {
+				continue
+			}
+
+			l.conflicts = append(l.conflicts, fmt.Sprintf("%s (from %s and %s)", entry.Name, owner, source))
+			continue
+		}
+
+		l.owners[entry.Name] = source
+		l.dirs[entry.Name] = entry.isDir()
+		l.entries = append(l.entries, entry)
+	}
+}
+
+func (l *entryList) err(sources string) error {
+	if len(l.conflicts) == 0 {
+		return nil
+	}
+
+	conflicts := append([]string(nil), l.conflicts...)
+	sort.Strings(conflicts)
+
+	return fmt.Errorf("conflicting files in %s:\n  %s", sources, strings.Join(conflicts, "\n  "))
+}
@@ -0,0 +1,534 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckIncludeMatchBelow(t *testing.T) {
+	includes := []string{"templates/**", "config/*.json", "static", "**/*.py"}
+
+	testCases := []struct {
+		dir      string
+		includes []string
+		want     bool
+	}{
+		{dir: "src", includes: includes[:3], want: false},
+		{dir: "src", includes: includes, want: true},
+		{dir: "src/nested", includes: includes, want: true},
+		{dir: "templates", includes: includes[:3], want: true},
+		{dir: "templates/email", includes: includes[:3], want: true},
+		{dir: "config", includes: includes[:3], want: true},
+		{dir: "config/env", includes: includes[:3], want: false},
+		{dir: "static/img", includes: includes[:3], want: true},
+		{dir: "staticfiles", includes: includes[:3], want: false},
+	}
+
+	for _, tc := range testCases {
+		got, err := checkIncludeMatchBelow(filepath.FromSlash(tc.dir), tc.includes)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got != tc.want {
+			t.Errorf("checkIncludeMatchBelow(%q, %q) = %t, want %t", tc.dir, tc.includes, got, tc.want)
+		}
+	}
+}
+
+func TestCheckIncludeMatch(t *testing.T) {
+	includes := []string{"**/*.py", "templates/**", "static"}
+
+	testCases := []struct {
+		file string
+		want bool
+	}{
+		{file: "main.py", want: true},
+		{file: "pkg/util.py", want: true},
+		{file: "templates/index.html", want: true},
+		{file: "static/img/logo.png", want: true},
+		{file: "README.md", want: false},
+		{file: "pkg/data.json", want: false},
+	}
+
+	for _, tc := range testCases {
+		got, err := checkIncludeMatch(filepath.FromSlash(tc.file), includes)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got != tc.want {
+			t.Errorf("checkIncludeMatch(%q) = %t, want %t", tc.file, got, tc.want)
+		}
+	}
+}
+
+func TestWalkDir_IgnoreFilesUsed(t *testing.T) {
+	dir := createTestIgnoreFilesDir(t)
+
+	var used []string
+	if _, err := walkDir(dir, ArchiveDirOpts{
+		IgnoreFiles:     []string{".gitignore", ".archiveignore"},
+		IgnoreFilesUsed: &used,
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{
+		filepath.ToSlash(filepath.Join(dir, ".gitignore")),
+		filepath.ToSlash(filepath.Join(dir, ".archiveignore")),
+		filepath.ToSlash(filepath.Join(dir, "src", ".gitignore")),
+	}
+	if !reflect.DeepEqual(used, want) {
+		t.Errorf("got ignore files %q, want %q", used, want)
+	}
+}
+
+func TestCheckFileInfoMatch(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	opts := ArchiveDirOpts{
+		ExcludeLargerThan: 1024,
+		ExcludeOlderThan:  now.Add(-time.Hour),
+		ExcludeFileTypes:  []string{FileTypeSocket, FileTypeFIFO, FileTypeDevice, FileTypeEmpty},
+	}
+
+	testCases := []struct {
+		name string
+		info testFileInfo
+		want bool
+	}{
+		{name: "regular", info: testFileInfo{size: 10, modTime: now}, want: false},
+		{name: "size limit", info: testFileInfo{size: 1024, modTime: now}, want: false},
+		{name: "large", info: testFileInfo{size: 1025, modTime: now}, want: true},
+		{name: "old", info: testFileInfo{size: 10, modTime: now.Add(-2 * time.Hour)}, want: true},
+		{name: "empty", info: testFileInfo{modTime: now}, want: true},
+		{name: "socket", info: testFileInfo{mode: os.ModeSocket, modTime: now}, want: true},
+		{name: "fifo", info: testFileInfo{mode: os.ModeNamedPipe, modTime: now}, want: true},
+		{name: "device", info: testFileInfo{mode: os.ModeDevice | os.ModeCharDevice, modTime: now}, want: true},
+	}
+
+	for _, tc := range testCases {
+		if got := checkFileInfoMatch(tc.info, opts); got != tc.want {
+			t.Errorf("%s: checkFileInfoMatch() = %t, want %t", tc.name, got, tc.want)
+		}
+
+		// Nothing is excluded without options.
+		if checkFileInfoMatch(tc.info, ArchiveDirOpts{}) {
+			t.Errorf("%s: excluded without options", tc.name)
+		}
+	}
+}
+
+func TestParseExcludeOlderThan(t *testing.T) {
+	now := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	testCases := map[string]time.Time{
+		"2024-01-01T00:00:00Z":      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		"2024-01-01T02:00:00+02:00": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		"720h":                      time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		"90m":                       time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC),
+	}
+
+	for value, want := range testCases {
+		got, err := parseExcludeOlderThan(value, now)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", value, err)
+		}
+		if !got.Equal(want) {
+			t.Errorf("%s: got %s, want %s", value, got, want)
+		}
+	}
+
+	for _, value := range []string{"", "yesterday", "-1h", "2024-01-01"} {
+		if _, err := parseExcludeOlderThan(value, now); err == nil {
+			t.Errorf("%q: expected error", value)
+		}
+	}
+}
+
+func TestWalkDir_ExcludeFileInfo(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"main.py":        "main",
+		"data/large.bin": "0123456789abcdef",
+		"old.txt":        "old",
+		"__init__.py":    "",
+	})
+
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "old.txt"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := walkDir(dir, ArchiveDirOpts{
+		ExcludeLargerThan: 8,
+		ExcludeOlderThan:  time.Now().Add(-24 * time.Hour),
+		ExcludeFileTypes:  []string{FileTypeEmpty},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got, want := entryNames(entries), []string{"main.py"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got files %q, want %q", got, want)
+	}
+}
+
+func TestWalkDir_StripComponents(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"README.md":           "readme",
+		"build/bin/app":       "app",
+		"build/lib/util.so":   "util",
+		"build/lib/a/deep.so": "deep",
+	})
+
+	entries, err := walkDir(dir, ArchiveDirOpts{TargetPrefix: "myapp-1.2.3/", StripComponents: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{"myapp-1.2.3/bin/app", "myapp-1.2.3/lib/a/deep.so", "myapp-1.2.3/lib/util.so"}
+	if got := entryNames(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("got files %q, want %q", got, want)
+	}
+
+	writeTestFiles(t, dir, map[string]string{"dist/bin/app": "other"})
+
+	_, err = walkDir(dir, ArchiveDirOpts{StripComponents: 1})
+
+	var collision *pathCollisionError
+	if !errors.As(err, &collision) || collision.collisions["bin/app"] == nil {
+		t.Fatalf("expected collision on bin/app, got: %v", err)
+	}
+}
+
+func TestWalkDir_Flatten(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"index.js":          "index",
+		"handlers/auth.js":  "auth",
+		"handlers/util.js":  "handlers util",
+		"lib/util.js":       "lib util",
+		"lib/deep/const.js": "const",
+	})
+
+	_, err := walkDir(dir, ArchiveDirOpts{Flatten: true})
+
+	var collision *pathCollisionError
+	if !errors.As(err, &collision) {
+		t.Fatalf("expected collision error, got: %v", err)
+	}
+	want := []string{filepath.Join(dir, "handlers", "util.js"), filepath.Join(dir, "lib", "util.js")}
+	if !reflect.DeepEqual(collision.collisions, map[string][]string{"util.js": want}) {
+		t.Errorf("got collisions %q, want util.js from %q", collision.collisions, want)
+	}
+
+	for policy, wantSource := range map[string]string{
+		OnCollisionFirst: filepath.Join(dir, "handlers", "util.js"),
+		OnCollisionLast:  filepath.Join(dir, "lib", "util.js"),
+	} {
+		entries, err := walkDir(dir, ArchiveDirOpts{TargetPrefix: "fn", Flatten: true, OnCollision: policy})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", policy, err)
+		}
+
+		want := []string{"fn/auth.js", "fn/const.js", "fn/index.js", "fn/util.js"}
+		if got := entryNames(entries); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got files %q, want %q", policy, got, want)
+		}
+
+		for _, entry := range entries {
+			if entry.Name == "fn/util.js" && entry.SourcePath != wantSource {
+				t.Errorf("%s: got util.js from %s, want %s", policy, entry.SourcePath, wantSource)
+			}
+		}
+	}
+}
+
+func TestWalkDir_SymlinkMode(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"lib/util.py":             "util",
+		"node_modules/pkg/cli.js": "cli",
+	})
+	createTestSymlink(t, filepath.Join("..", "pkg", "cli.js"), filepath.Join(dir, "node_modules", ".bin", "pkg"))
+	createTestSymlink(t, "lib", filepath.Join(dir, "current"))
+
+	testCases := map[string]map[string]string{
+		SymlinkModeFollow: {
+			"current/util.py":         "",
+			"lib/util.py":             "",
+			"node_modules/.bin/pkg":   "",
+			"node_modules/pkg/cli.js": "",
+		},
+		SymlinkModePreserve: {
+			"current":                 "lib",
+			"lib/util.py":             "",
+			"node_modules/.bin/pkg":   "../pkg/cli.js",
+			"node_modules/pkg/cli.js": "",
+		},
+		SymlinkModeSkip: {
+			"lib/util.py":             "",
+			"node_modules/pkg/cli.js": "",
+		},
+	}
+
+	for mode, want := range testCases {
+		entries, err := walkDir(dir, ArchiveDirOpts{SymlinkMode: mode})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", mode, err)
+		}
+
+		got := make(map[string]string, len(entries))
+		for _, entry := range entries {
+			got[entry.Name] = entry.LinkTarget
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got files %q, want %q", mode, got, want)
+		}
+	}
+}
+
+func TestWalkDir_SymlinkModePreserve_Outside(t *testing.T) {
+	outside := t.TempDir()
+	writeTestFiles(t, outside, map[string]string{"secret.txt": "secret"})
+
+	for _, target := range []string{
+		filepath.Join(outside, "secret.txt"),
+		filepath.Join("..", "..", "secret.txt"),
+		filepath.Join("..", "lib", "..", "..", "secret.txt"),
+	} {
+		dir := t.TempDir()
+		writeTestFiles(t, dir, map[string]string{"lib/util.py": "util"})
+		createTestSymlink(t, target, filepath.Join(dir, "lib", "secret.txt"))
+
+		if _, err := walkDir(dir, ArchiveDirOpts{SymlinkMode: SymlinkModePreserve}); err == nil {
+			t.Errorf("%s: expected error for symbolic link outside of the directory", target)
+		}
+	}
+}
+
+func TestWalkDir_SymlinkModePreserve_Renamed(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"node_modules/pkg/cli.js": "cli"})
+	createTestSymlink(t, filepath.Join("..", "pkg", "cli.js"), filepath.Join(dir, "node_modules", ".bin", "pkg"))
+
+	entries, err := walkDir(dir, ArchiveDirOpts{SymlinkMode: SymlinkModePreserve, StripComponents: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := []string{".bin/pkg", "pkg/cli.js"}; !reflect.DeepEqual(entryNames(entries), want) {
+		t.Errorf("got files %q, want %q", entryNames(entries), want)
+	}
+
+	// Links are checked against their name in the archive, which no longer has the directories their target is
+	// relative to.
+	for _, opts := range []ArchiveDirOpts{
+		{SymlinkMode: SymlinkModePreserve, Flatten: true},
+		{SymlinkMode: SymlinkModePreserve, StripComponents: 2},
+	} {
+		if _, err := walkDir(dir, opts); err == nil || !strings.Contains(err.Error(), "points outside of the archive") {
+			t.Errorf("%+v: expected error for symbolic link outside of the archive, got: %v", opts, err)
+		}
+	}
+}
+
+// createTestSymlink creates a symbolic link and its parent directories.
+func createTestSymlink(t *testing.T, target, link string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWalkDir_IncludeDirectories(t *testing.T) {
+	dir := createTestDirectoriesDir(t)
+
+	testCases := []struct {
+		name string
+		opts ArchiveDirOpts
+		want []string
+	}{
+		{
+			name: "directories",
+			opts: ArchiveDirOpts{Excludes: []string{"build/**"}},
+			want: []string{"app", "app/main.py", "lib", "lib/util.py", "logs", "tmp", "tmp/cache"},
+		},
+		{
+			name: "includes",
+			opts: ArchiveDirOpts{Includes: []string{"app/**", "tmp"}},
+			want: []string{"app", "app/main.py", "tmp", "tmp/cache"},
+		},
+		{
+			name: "strip components",
+			opts: ArchiveDirOpts{StripComponents: 1, Excludes: []string{"build/**"}},
+			want: []string{"cache", "main.py", "util.py"},
+		},
+		{
+			name: "flatten",
+			opts: ArchiveDirOpts{TargetPrefix: "fn", Flatten: true, Excludes: []string{"build/**"}},
+			want: []string{"fn/main.py", "fn/util.py"},
+		},
+		{
+			// Directories removed by the transforms are left out, and directories renamed to the same name are
+			// archived once.
+			name: "path transforms",
+			opts: ArchiveDirOpts{
+				Excludes:       []string{"build/**"},
+				PathTransforms: []PathTransform{{Match: regexp.MustCompile(`^(app|lib)/`), Replace: "src/"}},
+			},
+			want: []string{"logs", "src", "src/main.py", "src/util.py", "tmp", "tmp/cache"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc.opts.IncludeDirectories = true
+
+		entries, err := walkDir(dir, tc.opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", tc.name, err)
+		}
+
+		if got := entryNames(entries); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got files %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	// Directories of several source directories are archived once.
+	entries, err := walkDirs([]ArchiveDirSource{
+		{Path: dir, Opts: ArchiveDirOpts{Includes: []string{"app/**", "tmp"}, IncludeDirectories: true}},
+		{Path: dir, Opts: ArchiveDirOpts{Includes: []string{"lib/**", "tmp"}, IncludeDirectories: true}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{"app", "app/main.py", "lib", "lib/util.py", "tmp", "tmp/cache"}
+	if got := entryNames(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("got files %q, want %q", got, want)
+	}
+}
+
+func TestWalkDir_ExcludesGitignoreDescendants(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"package.json":              "{}",
+		"node_modules/.bin/mytool":  "mytool",
+		"node_modules/.bin/other":   "other",
+		"node_modules/pkg/index.js": "index",
+	})
+
+	testCases := []struct {
+		name     string
+		excludes []string
+		want     []string
+	}{
+		{
+			name:     "file",
+			excludes: []string{"node_modules/**", "!node_modules/.bin/mytool"},
+			want:     []string{"node_modules", "node_modules/.bin/mytool", "package.json"},
+		},
+		{
+			name:     "directory",
+			excludes: []string{"node_modules/**", "!node_modules/.bin/", "!node_modules/.bin/mytool"},
+			want:     []string{"node_modules", "node_modules/.bin", "node_modules/.bin/mytool", "package.json"},
+		},
+	}
+
+	for _, tc := range testCases {
+		entries, err := walkDir(dir, ArchiveDirOpts{
+			Excludes:           tc.excludes,
+			ExcludesSyntax:     ExcludesSyntaxGitignore,
+			IncludeDirectories: true,
+		})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", tc.name, err)
+		}
+
+		if got := entryNames(entries); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got files %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+// createTestDirectoriesDir creates a directory with empty directories.
+func createTestDirectoriesDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"app/main.py":   "main",
+		"lib/util.py":   "util",
+		"build/out.bin": "out",
+	})
+	for _, name := range []string{"tmp/cache", "logs"} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.FromSlash(name)), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+// testFileInfo is an os.FileInfo of a file which does not need to exist.
+type testFileInfo struct {
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (fi testFileInfo) Name() string       { return "file" }
+func (fi testFileInfo) Size() int64        { return fi.size }
+func (fi testFileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi testFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi testFileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi testFileInfo) Sys() any           { return nil }
+
+// entryNames returns the sorted names of entries.
+func entryNames(entries []ArchiveEntry) []string {
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ensureEntries checks the names and contents of entries, with symbolic links compared as "-> target".
+func ensureEntries(t *testing.T, entries []ArchiveEntry, wants map[string]string) {
+	t.Helper()
+
+	if len(entries) != len(wants) {
+		t.Errorf("mismatched file count, got %d, want %d", len(entries), len(wants))
+	}
+
+	for _, entry := range entries {
+		want, ok := wants[entry.Name]
+		if !ok {
+			t.Errorf("additional file in archive: %s", entry.Name)
+			continue
+		}
+
+		got := string(entry.Content)
+		if entry.LinkTarget != "" {
+			got = "-> " + entry.LinkTarget
+		}
+		if got != want {
+			t.Errorf("mismatched content for %s\n\tGot: %q\n\tWant: %q", entry.Name, got, want)
+		}
+	}
+}
@@ -11,22 +11,42 @@ import (
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"path/filepath"
+	"regexp"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/boolvalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/datasourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	fwpath "github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 var _ datasource.DataSource = (*archiveFileDataSource)(nil)
 
+// base64Regexp matches standard, padded base64 encoded content.
+var base64Regexp = regexp.MustCompile(`^(?:[A-Za-z0-9+/]{4})*(?:[A-Za-z0-9+/]{2}==|[A-Za-z0-9+/]{3}=)?$`)
+
+// sha256Regexp matches a hex encoded SHA256 checksum.
+var sha256Regexp = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// fileModeRegexp matches an octal file mode such as "0644".
+var fileModeRegexp = regexp.MustCompile(`^0?[0-7]{3}$`)
+
 func NewArchiveFileDataSource() datasource.DataSource {
 	return &archiveFileDataSource{}
 }
@@ -40,10 +60,21 @@ func (d *archiveFileDataSource) ConfigValidators(context.Context) []datasource.C
 			fwpath.MatchRoot("source_content_filename"),
 			fwpath.MatchRoot("source_file"),
 			fwpath.MatchRoot("source_dir"),
+			fwpath.MatchRoot("python_wheels"),
+			fwpath.MatchRoot("source_directory"),
+			fwpath.MatchRoot("source_git"),
+			fwpath.MatchRoot("source_archive"),
+			fwpath.MatchRoot("source_url"),
+			fwpath.MatchRoot("entry"),
 		),
 	}
 }
 
+func (d *archiveFileDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	resp.Diagnostics.Append(validateArchivePaths(ctx, req.Config)...)
+	resp.Diagnostics.Append(validateArchiveType(ctx, req.Config)...)
+}
+
 func (d *archiveFileDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Generates an archive from content, a file, or directory of files. " +
@@ -53,13 +84,28 @@ func (d *archiveFileDataSource) Schema(ctx context.Context, req datasource.Schem
 		Blocks: map[string]schema.Block{
 			"source": schema.SetNestedBlock{
 				Description: "Specifies attributes of a single source file to include into the archive. " +
-					"One and only one of `source`, `source_content_filename` (with `source_content`), `source_file`, " +
-					"or `source_dir` must be specified.",
+					"Only one of `source`, `source_content_filename` (with `source_content`), `source_file` or " +
+					"`source_dir` can be specified. The other sources, such as `source_directory` or `entry`, can be " +
+					"combined with it and with each other.",
 				NestedObject: schema.NestedBlockObject{
 					Attributes: map[string]schema.Attribute{
 						"content": schema.StringAttribute{
-							Description: "Add this content to the archive with `filename` as the filename.",
-							Required:    true,
+							Description: "Add this content to the archive with `filename` as the filename. " +
+								"Exactly one of `content` or `content_base64` must be specified.",
+							Optional: true,
+							Validators: []validator.String{
+								stringvalidator.ExactlyOneOf(
+									fwpath.MatchRelative().AtParent().AtName("content_base64"),
+								),
+							},
+						},
+						"content_base64": schema.StringAttribute{
+							Description: "Add this base64-encoded binary content to the archive with `filename` as " +
+								"the filename.",
+							Optional: true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(base64Regexp, "must be base64-encoded"),
+							},
 						},
 						"filename": schema.StringAttribute{
 							Description: "Set this as the filename when declaring a `source`.",
@@ -76,6 +122,385 @@ func (d *archiveFileDataSource) Schema(ctx context.Context, req datasource.Schem
 					),
 				},
 			},
+			"python_wheels": schema.ListNestedBlock{
+				Description: "Installs local Python wheel (`.whl`) files into the archive using the same layout as " +
+					"`pip install --target`: scripts and the launchers generated for `console_scripts` and `gui_scripts` " +
+					"entry points are installed executable in `bin`. Can be combined with any other source.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"paths": schema.ListAttribute{
+							Description: "Paths of the wheel files to install.",
+							ElementType: types.StringType,
+							Required:    true,
+						},
+						"target_prefix": schema.StringAttribute{
+							Description: "Directory inside the archive to install the wheels into, for example `python` " +
+								"for a Lambda layer. Defaults to the root of the archive.",
+							Optional: true,
+						},
+						"skip_record_rewrite": schema.BoolAttribute{
+							Description: "Boolean flag indicating whether each wheel's `*.dist-info/RECORD` should be " +
+								"archived as-is instead of being rewritten with the installed paths of relocated files. " +
+								"Defaults to `false`.",
+							Optional: true,
+						},
+					},
+				},
+			},
+			"source_directory": schema.ListNestedBlock{
+				Description: "Package the contents of a directory into the archive. Can be repeated to combine several " +
+					"directories into one archive, in which case the files of all directories are archived in sorted " +
+					"order and a file which would be archived under the same path from more than one directory is an error.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"path": schema.StringAttribute{
+							Description: "Path of the directory to package.",
+							Required:    true,
+						},
+						"target_prefix": schema.StringAttribute{
+							Description: "Directory inside the archive to place the files of this directory into. " +
+								"Defaults to the root of the archive.",
+							Optional: true,
+						},
+						"strip_components": schema.Int64Attribute{
+							Description: "Number of leading directories to remove from the paths of the files of this " +
+								"directory, like `tar --strip-components` does. Files with fewer directories are ignored. " +
+								"Defaults to `0`.",
+							Optional: true,
+							Validators: []validator.Int64{
+								int64validator.AtLeast(0),
+							},
+						},
+						"flatten": schema.BoolAttribute{
+							Description: "Boolean flag indicating whether the files of this directory should be archived " +
+								"by their base name, at the root of the archive or of `target_prefix`, dropping their " +
+								"directories. Files archived under the same name are resolved by `on_collision`. " +
+								"Defaults to `false`.",
+							Optional: true,
+						},
+						"includes": schema.SetAttribute{
+							Description: "Specify files/directories to package when reading this directory, relative to " +
+								"`path`, in which case only files matching one of the patterns and none of the `excludes` " +
+								"are archived. Supports glob file matching patterns including doublestar/globstar (`**`) patterns.",
+							ElementType: types.StringType,
+							Optional:    true,
+						},
+						"excludes": schema.ListAttribute{
+							Description: "Specify files/directories to ignore when reading this directory, relative to `path`. " +
+								"Supports glob file matching patterns including doublestar/globstar (`**`) patterns, or " +
+								"`.gitignore` patterns when `excludes_syntax` is `gitignore`, in which case their order " +
+								"matters.",
+							ElementType: types.StringType,
+							Optional:    true,
+						},
+						"excludes_syntax": schema.StringAttribute{
+							Description: "The syntax of `excludes`, either `glob` (default) or `gitignore`. See the " +
+								"top-level `excludes_syntax` for details.",
+							Optional: true,
+							Validators: []validator.String{
+								stringvalidator.OneOf(ExcludesSyntaxGlob, ExcludesSyntaxGitignore),
+							},
+						},
+						"ignore_files": schema.ListAttribute{
+							Description: "Names of ignore files, such as `.gitignore`, to read from this directory and " +
+								"each directory below it. See the top-level `ignore_files` for details.",
+							ElementType: types.StringType,
+							Optional:    true,
+						},
+						"exclude_larger_than": schema.Int64Attribute{
+							Description: "Exclude files of this directory larger than this many bytes.",
+							Optional:    true,
+							Validators: []validator.Int64{
+								int64validator.AtLeast(1),
+							},
+						},
+						"exclude_older_than": schema.StringAttribute{
+							Description: "Exclude files of this directory last modified before this time, either an RFC3339 " +
+								"timestamp or a duration before the time the archive is created, such as `720h`.",
+							Optional: true,
+						},
+						"exclude_presets": schema.SetAttribute{
+							Description: "Curated sets of excludes for common ecosystems, applied to this directory before " +
+								"`excludes`: `vcs`, `python`, `node`, `terraform` or `os_junk`. See the top-level " +
+								"`exclude_presets` for the patterns of each preset.",
+							ElementType: types.StringType,
+							Optional:    true,
+							Validators: []validator.Set{
+								setvalidator.ValueStringsAre(
+									stringvalidator.OneOf(excludePresetNames...),
+								),
+							},
+						},
+						"exclude_file_types": schema.SetAttribute{
+							Description: "Exclude files of this directory by type: `socket`, `fifo`, `device` (character and block " +
+								"devices) or `empty` (regular files without content).",
+							ElementType: types.StringType,
+							Optional:    true,
+							Validators: []validator.Set{
+								setvalidator.ValueStringsAre(
+									stringvalidator.OneOf(FileTypeSocket, FileTypeFIFO, FileTypeDevice, FileTypeEmpty),
+								),
+							},
+						},
+						"exclude_symlink_directories": schema.BoolAttribute{
+							Description: "Boolean flag indicating whether symbolically linked directories should be " +
+								"excluded when reading this directory. Defaults to `false`.",
+							Optional: true,
+						},
+						"symlink_mode": schema.StringAttribute{
+							Description: "How to archive the symbolic links of this directory: `follow`, `preserve` " +
+								"or `skip`, like the top-level `symlink_mode`. Defaults to `follow`.",
+							Optional: true,
+							Validators: []validator.String{
+								stringvalidator.OneOf(SymlinkModeFollow, SymlinkModePreserve, SymlinkModeSkip),
+							},
+						},
+					},
+				},
+			},
+			"source_git": schema.ListNestedBlock{
+				Description: "Package the files committed to a local git repository, like `git archive` does. The files " +
+					"are read from the object database of the repository, so untracked and modified files in the " +
+					"worktree are ignored, and are archived with the modes recorded in git. Symbolic links are " +
+					"archived as such whatever `symlink_mode`, and must point inside the archive. The SHA of the " +
+					"commit is exported as `source_git_commit`.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"repository_path": schema.StringAttribute{
+							Description: "Path of the git repository, either a worktree containing a `.git` directory or " +
+								"a bare repository.",
+							Required: true,
+						},
+						"ref": schema.StringAttribute{
+							Description: "The branch, tag or full commit SHA to archive, resolved like `git rev-parse` " +
+								"does. Defaults to `HEAD`.",
+							Optional: true,
+						},
+						"subdirectory": schema.StringAttribute{
+							Description: "Directory of the repository to archive, in which case the files are archived " +
+								"relative to it. Defaults to the root of the repository.",
+							Optional: true,
+						},
+					},
+				},
+				Validators: []validator.List{
+					listvalidator.SizeAtMost(1),
+				},
+			},
+			"source_archive": schema.ListNestedBlock{
+				Description: "Package the files of an existing zip or tar archive, optionally compressed with gzip or " +
+					"bzip2, without extracting it, for example to convert a zip to a tar.gz or to drop some of its " +
+					"files. Files keep the modes recorded in the archive, and symbolic links are archived as such " +
+					"whatever `symlink_mode`, and must point inside the new archive once `strip_components` and " +
+					"`target_prefix` are applied. Can be repeated, in which case a file which would be archived under " +
+					"the same path from more than one archive is an error.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"path": schema.StringAttribute{
+							Description: "Path of the archive to read.",
+							Required:    true,
+						},
+						"excludes": schema.SetAttribute{
+							Description: "Specify files/directories of the archive to ignore, relative to the root of " +
+								"the archive after `strip_components` is applied. Supports glob file matching patterns " +
+								"including doublestar/globstar (`**`) patterns.",
+							ElementType: types.StringType,
+							Optional:    true,
+						},
+						"strip_components": schema.Int64Attribute{
+							Description: "Number of leading directories to remove from the paths of the files of the " +
+								"archive, like `tar --strip-components` does. Files with fewer directories are ignored. " +
+								"Defaults to `0`.",
+							Optional: true,
+							Validators: []validator.Int64{
+								int64validator.AtLeast(0),
+							},
+						},
+						"target_prefix": schema.StringAttribute{
+							Description: "Directory inside the archive to place the files of this archive into. " +
+								"Defaults to the root of the archive.",
+							Optional: true,
+						},
+					},
+				},
+			},
+			"source_url": schema.ListNestedBlock{
+				Description: "Download a file over HTTP(S) into the archive. The file is cached by checksum, in the " +
+					"directory set by the `TF_ARCHIVE_CACHE_DIR` environment variable or else in the user cache " +
+					"directory, and is not downloaded again while the cached file matches `sha256`. Downloads time out " +
+					"after 10 minutes. Can be repeated.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"url": schema.StringAttribute{
+							Description: "The `http` or `https` URL of the file.",
+							Required:    true,
+						},
+						"sha256": schema.StringAttribute{
+							Description: "The hex-encoded SHA256 checksum the downloaded file must match before it is " +
+								"archived.",
+							Required: true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(sha256Regexp, "must be a hex-encoded SHA256 checksum"),
+							},
+						},
+						"target": schema.StringAttribute{
+							Description: "Path of the file inside the archive.",
+							Required:    true,
+						},
+						"mode": schema.StringAttribute{
+							Description: "String that specifies the octal file mode of the downloaded file, for example " +
+								"`\"0755\"`. Takes precedence over `output_file_mode` and `file_mode_rule` blocks. " +
+								"Downloaded files are otherwise archived with the mode `0644`, unless a `file_mode_rule` " +
+								"block or `auto_executable` sets it.",
+							Optional: true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(fileModeRegexp,
+									"must be an octal file mode such as \"0644\""),
+							},
+						},
+						"headers": schema.MapAttribute{
+							Description: "HTTP headers to send with the request, for example for authentication.",
+							ElementType: types.StringType,
+							Optional:    true,
+							Sensitive:   true,
+						},
+					},
+				},
+			},
+			"entry": schema.ListNestedBlock{
+				Description: "Adds a file, a directory or inline content to the archive. Can be repeated and combined " +
+					"with any other source, and entries are archived in the order they are declared. Exactly one of " +
+					"`content`, `content_base64`, `file` or `directory` must be specified.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"content": schema.StringAttribute{
+							Description: "Add this content to the archive with `target` as the filename.",
+							Optional:    true,
+							Validators: []validator.String{
+								stringvalidator.ExactlyOneOf(
+									fwpath.MatchRelative().AtParent().AtName("content_base64"),
+									fwpath.MatchRelative().AtParent().AtName("file"),
+									fwpath.MatchRelative().AtParent().AtName("directory"),
+								),
+								stringvalidator.AlsoRequires(fwpath.MatchRelative().AtParent().AtName("target")),
+							},
+						},
+						"content_base64": schema.StringAttribute{
+							Description: "Add this base64-encoded binary content to the archive with `target` as the filename.",
+							Optional:    true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(base64Regexp, "must be base64-encoded"),
+								stringvalidator.AlsoRequires(fwpath.MatchRelative().AtParent().AtName("target")),
+							},
+						},
+						"file": schema.StringAttribute{
+							Description: "Package this file into the archive, with `target` as the filename if set or " +
+								"the base name of the file otherwise.",
+							Optional: true,
+						},
+						"directory": schema.StringAttribute{
+							Description: "Package entire contents of this directory into the archive, below `target` " +
+								"if set or at the root of the archive otherwise.",
+							Optional: true,
+						},
+						"target": schema.StringAttribute{
+							Description: "The path of the entry inside the archive. Required for `content` and " +
+								"`content_base64`.",
+							Optional: true,
+						},
+						"mode": schema.StringAttribute{
+							Description: "String that specifies the octal file mode of the archived files of this entry, " +
+								"for example `\"0755\"`. Takes precedence over `output_file_mode`.",
+							Optional: true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(fileModeRegexp,
+									"must be an octal file mode such as \"0644\""),
+							},
+						},
+						"mtime": schema.StringAttribute{
+							Description: "RFC3339 timestamp to record as the modification time of the archived files " +
+								"of this entry, for example `\"2024-01-01T00:00:00Z\"`.",
+							Optional: true,
+						},
+					},
+				},
+			},
+			"file_mode_rule": schema.ListNestedBlock{
+				Description: "Set the mode of the archived files matching `pattern`, taking precedence over " +
+					"`auto_executable` and `output_file_mode`. Rules are evaluated in order and the first rule matching " +
+					"a file sets its mode. Files of `entry` blocks with a `mode` keep it.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"pattern": schema.StringAttribute{
+							Description: "Glob pattern matched against the path of each file inside the archive, " +
+								"including doublestar/globstar (`**`) patterns, for example `bin/**` or `**/*.sh`.",
+							Required: true,
+						},
+						"mode": schema.StringAttribute{
+							Description: "String that specifies the octal file mode of the matching files, for example " +
+								"`\"0755\"`.",
+							Required: true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(fileModeRegexp, "must be an octal file mode such as \"0644\""),
+							},
+						},
+					},
+				},
+			},
+			"owner_rule": schema.ListNestedBlock{
+				Description: "Set the owner and the group of the files of tar archives matching `pattern`, taking " +
+					"precedence over `owner` and `group`. Rules are evaluated in order and the first rule matching a " +
+					"file sets its owner and group. Not supported by zip archives.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"pattern": schema.StringAttribute{
+							Description: "Glob pattern matched against the path of each file inside the archive, " +
+								"including doublestar/globstar (`**`) patterns, for example `bin/**` or `**/*.sh`.",
+							Required: true,
+						},
+						"owner": schema.StringAttribute{
+							Description: "The owner of the matching files, given like `owner`. Defaults to `owner`.",
+							Optional:    true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(ownerRegexp, "must be an ID, a name or a name:ID pair"),
+							},
+						},
+						"group": schema.StringAttribute{
+							Description: "The group of the matching files, given like `group`. Defaults to `group`.",
+							Optional:    true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(ownerRegexp, "must be an ID, a name or a name:ID pair"),
+							},
+						},
+					},
+				},
+			},
+			"path_transform": schema.ListNestedBlock{
+				Description: "Rename files on their way into the archive, like `tar --transform` does. Transforms are " +
+					"applied in order to the slash separated path of each file of `source_dir` and `source_directory`, " +
+					"relative to its directory, and to the filenames of `source`, each to the result of the previous " +
+					"one. Two files renamed to the same path are an error.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"match": schema.StringAttribute{
+							Description: "Regular expression, in RE2 syntax, matched against the path of each file.",
+							Required:    true,
+						},
+						"replace": schema.StringAttribute{
+							Description: "Replacement of every match of `match`, in which `$1` or `${name}` refer to " +
+								"its submatches. An empty string removes the matches.",
+							Required: true,
+						},
+					},
+				},
+				Validators: []validator.List{
+					listvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+			},
 		},
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
@@ -88,20 +513,36 @@ func (d *archiveFileDataSource) Schema(ctx context.Context, req datasource.Schem
 			},
 			"source_content": schema.StringAttribute{
 				Description: "Add only this content to the archive with `source_content_filename` as the filename. " +
-					"One and only one of `source`, `source_content_filename` (with `source_content`), `source_file`, " +
-					"or `source_dir` must be specified.",
+					"Only one of `source`, `source_content_filename` (with `source_content`), `source_file` or " +
+					"`source_dir` can be specified. The other sources, such as `source_directory` or `entry`, can be " +
+					"combined with it and with each other.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_dir"),
+					),
+				},
+			},
+			"source_content_base64": schema.StringAttribute{
+				Description: "Add only this base64-encoded binary content to the archive with " +
+					"`source_content_filename` as the filename.",
 				Optional: true,
 				Validators: []validator.String{
+					stringvalidator.RegexMatches(base64Regexp, "must be base64-encoded"),
 					stringvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_content"),
 						fwpath.MatchRoot("source_file"),
 						fwpath.MatchRoot("source_dir"),
 					),
+					stringvalidator.AlsoRequires(fwpath.MatchRoot("source_content_filename")),
 				},
 			},
 			"source_content_filename": schema.StringAttribute{
 				Description: "Set this as the filename when using `source_content`. " +
-					"One and only one of `source`, `source_content_filename` (with `source_content`), `source_file`, " +
-					"or `source_dir` must be specified.",
+					"Only one of `source`, `source_content_filename` (with `source_content`), `source_file` or " +
+					"`source_dir` can be specified. The other sources, such as `source_directory` or `entry`, can be " +
+					"combined with it and with each other.",
 				Optional: true,
 				Validators: []validator.String{
 					stringvalidator.ConflictsWith(
@@ -112,8 +553,9 @@ func (d *archiveFileDataSource) Schema(ctx context.Context, req datasource.Schem
 			},
 			"source_file": schema.StringAttribute{
 				Description: "Package this file into the archive. " +
-					"One and only one of `source`, `source_content_filename` (with `source_content`), `source_file`, " +
-					"or `source_dir` must be specified.",
+					"Only one of `source`, `source_content_filename` (with `source_content`), `source_file` or " +
+					"`source_dir` can be specified. The other sources, such as `source_directory` or `entry`, can be " +
+					"combined with it and with each other.",
 				Optional: true,
 				Validators: []validator.String{
 					stringvalidator.ConflictsWith(
@@ -123,10 +565,19 @@ func (d *archiveFileDataSource) Schema(ctx context.Context, req datasource.Schem
 					),
 				},
 			},
+			"source_file_target": schema.StringAttribute{
+				Description: "Path inside the archive to store `source_file` as, for example `bin/app`. Defaults to " +
+					"the name of `source_file`.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(fwpath.MatchRoot("source_file")),
+				},
+			},
 			"source_dir": schema.StringAttribute{
 				Description: "Package entire contents of this directory into the archive. " +
-					"One and only one of `source`, `source_content_filename` (with `source_content`), `source_file`, " +
-					"or `source_dir` must be specified.",
+					"Only one of `source`, `source_content_filename` (with `source_content`), `source_file` or " +
+					"`source_dir` can be specified. The other sources, such as `source_directory` or `entry`, can be " +
+					"combined with it and with each other.",
 				Optional: true,
 				Validators: []validator.String{
 					stringvalidator.ConflictsWith(
@@ -136,8 +587,59 @@ func (d *archiveFileDataSource) Schema(ctx context.Context, req datasource.Schem
 					),
 				},
 			},
-			"excludes": schema.SetAttribute{
-				Description: "Specify files/directories to ignore when reading the `source_dir`. " +
+			"target_prefix": schema.StringAttribute{
+				Description: "Directory inside the archive to place the files of `source_dir` into, for example " +
+					"`myapp-1.2.3` for a release archive which unpacks into its own directory. Defaults to the root " +
+					"of the archive.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+			},
+			"strip_components": schema.Int64Attribute{
+				Description: "Number of leading directories to remove from the paths of the files of `source_dir`, " +
+					"like `tar --strip-components` does. Files with fewer directories are ignored. Defaults to `0`.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+					int64validator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+			},
+			"flatten": schema.BoolAttribute{
+				Description: "Boolean flag indicating whether the files of `source_dir` should be archived by their " +
+					"base name, at the root of the archive or of `target_prefix`, dropping their directories. Files " +
+					"archived under the same name are resolved by `on_collision`. Defaults to `false`.",
+				Optional: true,
+				Validators: []validator.Bool{
+					boolvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+			},
+			"on_collision": schema.StringAttribute{
+				Description: "What to do when `flatten`, `strip_components` or `path_transform` would archive several " +
+					"files of the same directory, or of `source`, under the same name: `error` (default) lists the " +
+					"conflicting files, `first` keeps the file walked first and `last` the file walked last, in " +
+					"lexical order.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(OnCollisionError, OnCollisionFirst, OnCollisionLast),
+				},
+			},
+			"includes": schema.SetAttribute{
+				Description: "Specify files/directories to package when reading the `source_dir`, in which case only " +
+					"files matching one of the patterns and none of the `excludes` are archived. A pattern matching a " +
+					"directory includes everything below it. " +
 					"Supports glob file matching patterns including doublestar/globstar (`**`) patterns.",
 				ElementType: types.StringType,
 				Optional:    true,
@@ -149,17 +651,200 @@ func (d *archiveFileDataSource) Schema(ctx context.Context, req datasource.Schem
 					),
 				},
 			},
+			"excludes": schema.ListAttribute{
+				Description: "Specify files/directories to ignore when reading the `source_dir`. " +
+					"Supports glob file matching patterns including doublestar/globstar (`**`) patterns, or " +
+					"`.gitignore` patterns when `excludes_syntax` is `gitignore`, in which case their order matters.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.List{
+					listvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+			},
+			"excludes_syntax": schema.StringAttribute{
+				Description: "The syntax of `excludes`. With `glob` (default), a file is excluded when it matches any " +
+					"of the patterns. With `gitignore`, the patterns follow the `.gitignore` format and are evaluated " +
+					"in order, with the last matching pattern taking precedence: a `!` prefix re-includes files " +
+					"excluded by an earlier pattern, a trailing `/` only matches directories, and patterns without a " +
+					"`/` match at any level while other patterns are relative to `source_dir`. As with git, a file " +
+					"cannot be re-included if one of its parent directories is excluded, except for the directories " +
+					"inside `dir` excluded by a `dir/**` pattern, which only matches the paths inside `dir`: " +
+					"`node_modules/**` followed by `!node_modules/.bin/mytool` archives `mytool` alone.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(ExcludesSyntaxGlob, ExcludesSyntaxGitignore),
+					stringvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+			},
+			"ignore_files": schema.ListAttribute{
+				Description: "Names of ignore files, for example `[\".gitignore\", \".archiveignore\"]`, to read from " +
+					"`source_dir` and each directory below it. The patterns of an ignore file follow the `.gitignore` " +
+					"format and apply to the files below its directory, with the patterns of deeper ignore files, and " +
+					"of later names in the list, taking precedence. Files excluded by `excludes` cannot be re-included " +
+					"by an ignore file.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.List{
+					listvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+			},
+			"exclude_larger_than": schema.Int64Attribute{
+				Description: "Exclude files of `source_dir` larger than this many bytes.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+					int64validator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+			},
+			"exclude_older_than": schema.StringAttribute{
+				Description: "Exclude files of `source_dir` last modified before this time, either an RFC3339 " +
+					"timestamp or a duration before the time the archive is created, such as `720h`.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+			},
+			"exclude_presets": schema.SetAttribute{
+				Description: "Curated sets of excludes for common ecosystems, applied to `source_dir` before `excludes`. " +
+					"With the `gitignore` syntax, `excludes` can re-include files excluded by a preset. The patterns " +
+					"match files or directories at any level, and none of the files of a matching directory are " +
+					"archived: " + excludePresetsDescription() + ".",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Set{
+					setvalidator.ValueStringsAre(
+						stringvalidator.OneOf(excludePresetNames...),
+					),
+					setvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+			},
+			"exclude_file_types": schema.SetAttribute{
+				Description: "Exclude files of `source_dir` by type: `socket`, `fifo`, `device` (character and block " +
+					"devices) or `empty` (regular files without content).",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Set{
+					setvalidator.ValueStringsAre(
+						stringvalidator.OneOf(FileTypeSocket, FileTypeFIFO, FileTypeDevice, FileTypeEmpty),
+					),
+					setvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+			},
 			"exclude_symlink_directories": schema.BoolAttribute{
 				Optional: true,
 				Description: "Boolean flag indicating whether symbolically linked directories should be excluded during " +
 					"the creation of the archive. Defaults to `false`.",
 			},
+			"symlink_mode": schema.StringAttribute{
+				Description: "How to archive the symbolic links of `source_dir`. `follow` archives the files they point " +
+					"to, and the content of the directories they point to unless `exclude_symlink_directories` is set, " +
+					"`preserve` archives them as symbolic links, which must point inside `source_dir`, and `skip` " +
+					"leaves them out. Defaults to `follow`.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(SymlinkModeFollow, SymlinkModePreserve, SymlinkModeSkip),
+					stringvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+			},
+			"include_directories": schema.BoolAttribute{
+				Description: "Boolean flag indicating whether the directories of `source_dir` and `source_directory` " +
+					"blocks, including empty ones, should be archived as directory entries, with the mode `0755` " +
+					"unless `directory_mode` is set. With `includes`, only the directories matching them are " +
+					"archived. Defaults to `false`.",
+				Optional: true,
+				Validators: []validator.Bool{
+					boolvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+			},
+			"node_prune_dev_dependencies": schema.BoolAttribute{
+				Description: "Boolean flag indicating whether only production dependencies should be included from " +
+					"`node_modules` when reading the `source_dir`. The dependency graph is read from the " +
+					"`package-lock.json` (`lockfileVersion` 2 or 3) at the root of `source_dir`, and files below " +
+					"`node_modules` which do not belong to a production package are excluded, except the links of " +
+					"`node_modules/.bin` to the executables of production packages. Defaults to `false`.",
+				Optional: true,
+				Validators: []validator.Bool{
+					boolvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+			},
 			"output_path": schema.StringAttribute{
 				Description: "The output of the archive file.",
 				Required:    true,
 			},
 			"output_size": schema.Int64Attribute{
-				Description: "The byte size of the output archive file.",
+				Description: "The byte size of the output archive file. When `split_size` is set, this is the size of " +
+					"the archive before it is split.",
+				Computed: true,
+			},
+			"split_size": schema.Int64Attribute{
+				Description: "Split the output into volumes of at most this many bytes, which must be at least 65536. " +
+					"`zip` archives are written as a standard split archive, where all but the last volume are named " +
+					"with a `.z01`, `.z02`, ... extension and the last volume is written to `output_path`. Other " +
+					"archive types are written to `output_path.001`, `output_path.002`, ... instead of `output_path`. " +
+					"The `output_*` checksums are those of the archive before it is split, which are the same as " +
+					"those of the archive written without `split_size`, and the checksum of each volume is available " +
+					"in `output_parts`. The volumes of `tar.gz` archives concatenated yield the archive, while those " +
+					"of `zip` archives record offsets relative to each volume and must be reassembled, for example " +
+					"with `zip -s 0`.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(minSplitSize),
+				},
+			},
+			"output_parts": schema.ListAttribute{
+				Description: "The volumes written when `split_size` is set, in order. Each volume has a `path`, " +
+					"a `size` in bytes and a `sha256` checksum.",
+				ElementType: types.ObjectType{AttrTypes: outputPartAttrTypes},
+				Computed:    true,
+			},
+			"ignore_files_used": schema.ListAttribute{
+				Description: "The paths of the ignore files read when `ignore_files` is set, in the order they " +
+					"were read.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"source_git_commit": schema.StringAttribute{
+				Description: "The SHA of the commit archived by `source_git`.",
 				Computed:    true,
 			},
 			"output_file_mode": schema.StringAttribute{
@@ -168,6 +853,94 @@ func (d *archiveFileDataSource) Schema(ctx context.Context, req datasource.Schem
 					"files (and ultimately checksums) resulting in more deterministic behavior.",
 				Optional: true,
 			},
+			"directory_mode": schema.StringAttribute{
+				Description: "String that specifies the octal file mode of the directory entries of the archive, " +
+					"written when `include_directories` is set, for example `\"0755\"`.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(fileModeRegexp, "must be an octal file mode such as \"0755\""),
+				},
+			},
+			"auto_executable": schema.BoolAttribute{
+				Description: "Boolean flag indicating whether archived files starting with a shebang (`#!`) or the " +
+					"header of an ELF or Mach-O binary should get the mode `0755`, and every other file `0644`, " +
+					"regardless of their mode on disk. Takes precedence over `output_file_mode`. Defaults to `false`.",
+				Optional: true,
+			},
+			"mtime_mode": schema.StringAttribute{
+				Description: "How to set the modification time of the archived files. `zero` records the zero time, " +
+					"stored as 1980-01-01 by zip and 0001-01-01 by tar, `fixed` records `mtime`, `source_date_epoch` " +
+					"records the time set by the `SOURCE_DATE_EPOCH` environment variable, and `preserve` records the " +
+					"modification time of the files on disk. Files of `entry` blocks with a `mtime` keep it. Zip " +
+					"archives cannot record times before 1980-01-01, which are an error unless preserved from the " +
+					"files on disk. Defaults to `zero`.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(MtimeModeZero, MtimeModeFixed, MtimeModeSourceDateEpoch, MtimeModePreserve),
+				},
+			},
+			"mtime": schema.StringAttribute{
+				Description: "RFC3339 timestamp to record as the modification time of the archived files when " +
+					"`mtime_mode` is `fixed`, for example `\"2024-01-01T00:00:00Z\"`.",
+				Optional: true,
+			},
+			"owner": schema.StringAttribute{
+				Description: "The user owning the files of tar archives, given as an ID, a name, or a `name:ID` pair " +
+					"like the `--owner` option of GNU tar, for example `\"1000\"`, `\"root\"` or `\"app:1000\"`. " +
+					"Names are recorded as is, with the ID `0` when none is given, rather than looked up. Defaults " +
+					"to the ID `0` with no name. Not supported by zip archives.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(ownerRegexp, "must be an ID, a name or a name:ID pair"),
+				},
+			},
+			"group": schema.StringAttribute{
+				Description: "The group owning the files of tar archives, given like `owner`. Defaults to the ID " +
+					"`0` with no name. Not supported by zip archives.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(ownerRegexp, "must be an ID, a name or a name:ID pair"),
+				},
+			},
+			"tar_format": schema.StringAttribute{
+				Description: "The format of the headers of `tar.gz` archives, one of `ustar`, `pax` or `gnu`. `ustar` " +
+					"is the most widely readable, but cannot represent paths longer than 256 characters among other " +
+					"limits, and files it cannot represent are an error. `pax` and `gnu` represent long paths with " +
+					"extension headers. By default, the format of each file is the most widely readable one which " +
+					"can represent it.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(TarFormatUSTAR, TarFormatPAX, TarFormatGNU),
+				},
+			},
+			"preserve_xattrs": schema.BoolAttribute{
+				Description: "Boolean flag indicating whether the extended attributes of the archived files, such as " +
+					"file capabilities (`security.capability`) and SELinux labels (`security.selinux`), should be " +
+					"recorded as `SCHILY.xattr.*` PAX records of `tar.gz` archives. Extended attributes are only " +
+					"read on Linux, and are not supported by zip archives. Defaults to `false`.",
+				Optional: true,
+			},
+			"xattr_namespaces": schema.SetAttribute{
+				Description: "The namespaces of the extended attributes recorded when `preserve_xattrs` is set: " +
+					"`security`, `system` (including POSIX ACLs), `trusted` (only readable with `CAP_SYS_ADMIN`) " +
+					"or `user`. Defaults to `security` and `user`. Not supported by zip archives.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Set{
+					setvalidator.ValueStringsAre(
+						stringvalidator.OneOf(XattrNamespaceSecurity, XattrNamespaceSystem, XattrNamespaceTrusted,
+							XattrNamespaceUser),
+					),
+					setvalidator.AlsoRequires(fwpath.MatchRoot("preserve_xattrs")),
+				},
+			},
+			"preserve_hardlinks": schema.BoolAttribute{
+				Description: "Boolean flag indicating whether archived files which are hard links to the same file " +
+					"should be stored once in `tar.gz` archives, the first of them in the archive as a file and the " +
+					"others as hard links to it. Hard links are only detected on Unix systems, and are not supported by " +
+					"zip archives. Defaults to `false`.",
+				Optional: true,
+			},
 			"output_md5": schema.StringAttribute{
 				Description: "MD5 of output file",
 				Computed:    true,
@@ -192,73 +965,730 @@ func (d *archiveFileDataSource) Schema(ctx context.Context, req datasource.Schem
 				Description: "Base64 Encoded SHA512 checksum of output file",
 				Computed:    true,
 			},
-		},
+		},
+	}
+}
+
+func archive(ctx context.Context, model *fileModel) error {
+	archiveType := model.Type.ValueString()
+	outputPath := model.OutputPath.ValueString()
+
+	archiver := getArchiver(archiveType, outputPath)
+	if archiver == nil {
+		return fmt.Errorf("archive type not supported: %s", archiveType)
+	}
+
+	outputFileMode := model.OutputFileMode.ValueString()
+	if outputFileMode != "" {
+		archiver.SetOutputFileMode(outputFileMode)
+	}
+
+	if tarArchiver, ok := archiver.(*TarArchiver); !ok {
+		if attributes := tarOnlyAttributes(model); len(attributes) > 0 {
+			return fmt.Errorf("%s is not supported by %s archives", attributes[0], archiveType)
+		}
+	} else if !model.TarFormat.IsNull() {
+		if err := tarArchiver.SetFormat(model.TarFormat.ValueString()); err != nil {
+			return err
+		}
+	}
+
+	entries, err := archiveEntries(ctx, model)
+	if err != nil {
+		return err
+	}
+
+	if err := applyEntryOpts(ctx, model, entries); err != nil {
+		return fmt.Errorf("error archiving entries: %s", err)
+	}
+
+	if err := assertNotEmpty(entries); err != nil {
+		return err
+	}
+
+	if err := archiver.ArchiveEntries(entries); err != nil {
+		return fmt.Errorf("error archiving entries: %s", err)
+	}
+
+	return nil
+}
+
+// applyEntryOpts sets the modes, modification times, owners, extended attributes and hard links of entries configured
+// in model.
+func applyEntryOpts(ctx context.Context, model *fileModel, entries []ArchiveEntry) error {
+	modes, err := fileModeOpts(ctx, model)
+	if err != nil {
+		return err
+	}
+
+	if err := applyFileModes(entries, modes); err != nil {
+		return err
+	}
+
+	var mtime time.Time
+	if !model.Mtime.IsNull() {
+		mtime, err = parseMtime(model.Mtime.ValueString())
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := applyModTimes(entries, model.Type.ValueString(), model.MtimeMode.ValueString(), mtime); err != nil {
+		return err
+	}
+
+	owners, err := ownerOpts(ctx, model)
+	if err != nil {
+		return err
+	}
+
+	if err := applyOwners(entries, owners); err != nil {
+		return err
+	}
+
+	if model.PreserveXattrs.ValueBool() {
+		var namespaces []string
+		model.XattrNamespaces.ElementsAs(ctx, &namespaces, false)
+
+		if err := applyXattrs(entries, namespaces); err != nil {
+			return err
+		}
+	}
+
+	if model.PreserveHardlinks.ValueBool() {
+		applyHardLinks(entries)
+	}
+
+	return nil
+}
+
+// archiveEntries returns the entries of every source configured in model, in the order they are archived, and sets
+// the computed attributes describing the sources, such as the ignore files read while walking its directories.
+func archiveEntries(ctx context.Context, model *fileModel) ([]ArchiveEntry, error) {
+	var entries entryList
+
+	var ignoreFilesUsed []string
+	readsIgnoreFiles := false
+
+	if !model.SourceContentFilename.IsNull() {
+		content := []byte(model.SourceContent.ValueString())
+
+		if !model.SourceContentBase64.IsNull() {
+			var err error
+			content, err = base64.StdEncoding.DecodeString(model.SourceContentBase64.ValueString())
+			if err != nil {
+				return nil, fmt.Errorf("error archiving content: could not decode source_content_base64: %s", err)
+			}
+		}
+
+		entries.add("source_content", ArchiveEntry{
+			Name:    filepath.ToSlash(model.SourceContentFilename.ValueString()),
+			Content: content,
+		})
+	}
+
+	if !model.SourceFile.IsNull() {
+		entry, err := fileEntry(model.SourceFile.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("error archiving file: %s", err)
+		}
+
+		if !model.SourceFileTarget.IsNull() {
+			entry.Name, err = cleanArchivePath(model.SourceFileTarget.ValueString())
+			if err != nil {
+				return nil, fmt.Errorf("error archiving file: invalid source_file_target: %s", err)
+			}
+		}
+
+		entries.add("source_file", entry)
+	}
+
+	transforms, err := pathTransforms(ctx, model.PathTransforms)
+	if err != nil {
+		return nil, err
+	}
+
+	if !model.SourceDir.IsNull() {
+		opts, err := sourceDirOpts(ctx, model, transforms)
+		if err != nil {
+			return nil, fmt.Errorf("error archiving directory: %s", err)
+		}
+
+		if !model.IgnoreFiles.IsNull() {
+			opts.IgnoreFilesUsed = &ignoreFilesUsed
+			readsIgnoreFiles = true
+		}
+
+		dirEntries, err := walkDir(model.SourceDir.ValueString(), opts)
+		if err == nil {
+			err = assertNotEmpty(dirEntries)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error archiving directory: %s", err)
+		}
+
+		entries.add("source_dir", dirEntries...)
+	}
+
+	if len(model.SourceDirectories.Elements()) > 0 {
+		dirs, err := sourceDirectories(ctx, model, transforms)
+		if err != nil {
+			return nil, fmt.Errorf("error archiving directories: %s", err)
+		}
+
+		var elements []sourceDirectoryModel
+		model.SourceDirectories.ElementsAs(ctx, &elements, false)
+
+		for i, elem := range elements {
+			dirs[i].Opts.IgnoreFilesUsed = &ignoreFilesUsed
+			if !elem.IgnoreFiles.IsNull() {
+				readsIgnoreFiles = true
+			}
+		}
+
+		dirEntries, err := walkDirs(dirs)
+		if err == nil {
+			err = assertNotEmpty(dirEntries)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error archiving directories: %s", err)
+		}
+
+		entries.add("source_directory", dirEntries...)
+	}
+
+	model.SourceGitCommit = types.StringNull()
+	if len(model.SourceGit.Elements()) > 0 {
+		var elements []sourceGitModel
+		model.SourceGit.ElementsAs(ctx, &elements, false)
+
+		commit, repoEntries, err := gitEntries(GitSourceOpts{
+			RepositoryPath: elements[0].RepositoryPath.ValueString(),
+			Ref:            elements[0].Ref.ValueString(),
+			Subdirectory:   elements[0].Subdirectory.ValueString(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error archiving git repository: %s", err)
+		}
+
+		model.SourceGitCommit = types.StringValue(commit)
+		entries.add("source_git", repoEntries...)
+	}
+
+	var archiveElements []sourceArchiveModel
+	model.SourceArchives.ElementsAs(ctx, &archiveElements, false)
+
+	for _, elem := range archiveElements {
+		var excludes []string
+		elem.Excludes.ElementsAs(ctx, &excludes, false)
+
+		files, err := sourceArchiveEntries(elem.Path.ValueString(), SourceArchiveOpts{
+			Excludes:        excludes,
+			StripComponents: int(elem.StripComponents.ValueInt64()),
+			TargetPrefix:    elem.TargetPrefix.ValueString(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error archiving source archive: %s", err)
+		}
+
+		entries.add(elem.Path.ValueString(), files...)
+	}
+
+	var urlElements []sourceURLModel
+	model.SourceURLs.ElementsAs(ctx, &urlElements, false)
+
+	for _, elem := range urlElements {
+		var headers map[string]string
+		elem.Headers.ElementsAs(ctx, &headers, false)
+
+		entry, err := sourceURLEntry(ctx, elem.Target.ValueString(), SourceURLOpts{
+			URL:     elem.URL.ValueString(),
+			SHA256:  elem.SHA256.ValueString(),
+			Headers: headers,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error archiving source url: %s", err)
+		}
+
+		if !elem.Mode.IsNull() {
+			mode, err := parseFileMode(elem.Mode.ValueString())
+			if err != nil {
+				return nil, fmt.Errorf("error archiving source url: %s", err)
+			}
+			entry.setMode(mode)
+		}
+
+		entries.add(elem.URL.ValueString(), entry)
+	}
+
+	if !model.Source.IsNull() {
+		content := make(map[string][]byte)
+
+		var elements []sourceModel
+		model.Source.ElementsAs(ctx, &elements, false)
+
+		for _, elem := range elements {
+			if elem.ContentBase64.IsNull() {
+				content[elem.Filename.ValueString()] = []byte(elem.Content.ValueString())
+				continue
+			}
+
+			data, err := base64.StdEncoding.DecodeString(elem.ContentBase64.ValueString())
+			if err != nil {
+				return nil, fmt.Errorf("error archiving content: could not decode content_base64 of %s: %s",
+					elem.Filename.ValueString(), err)
+			}
+			content[elem.Filename.ValueString()] = data
+		}
+
+		content, err := transformContent(content, transforms, model.OnCollision.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("error archiving content: %s", err)
+		}
+
+		entries.add("source", contentEntries(content)...)
+	}
+
+	var wheels []pythonWheelsModel
+	model.PythonWheels.ElementsAs(ctx, &wheels, false)
+
+	for i, wheel := range wheels {
+		var paths []string
+		wheel.Paths.ElementsAs(ctx, &paths, false)
+
+		wheelEntries, err := installPythonWheels(paths, PythonWheelOpts{
+			TargetPrefix:      wheel.TargetPrefix.ValueString(),
+			SkipRecordRewrite: wheel.SkipRecordRewrite.ValueBool(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error installing python wheels: %s", err)
+		}
+
+		entries.add(fmt.Sprintf("python_wheels %d", i+1), wheelEntries...)
+	}
+
+	var entryElements []entryModel
+	model.Entries.ElementsAs(ctx, &entryElements, false)
+
+	for i, elem := range entryElements {
+		blockEntries, err := elem.archiveEntries()
+		if err != nil {
+			return nil, fmt.Errorf("error archiving entry %d: %s", i+1, err)
+		}
+
+		entries.add(fmt.Sprintf("entry %d", i+1), blockEntries...)
+	}
+
+	if err := entries.err("archive"); err != nil {
+		return nil, err
+	}
+
+	model.IgnoreFilesUsed = types.ListNull(types.StringType)
+	if readsIgnoreFiles {
+		elements := make([]attr.Value, len(ignoreFilesUsed))
+		for i, filename := range ignoreFilesUsed {
+			elements[i] = types.StringValue(filename)
+		}
+		model.IgnoreFilesUsed = types.ListValueMust(types.StringType, elements)
+	}
+
+	return entries.entries, nil
+}
+
+// sourceDirOpts returns the options of source_dir configured in model.
+func sourceDirOpts(ctx context.Context, model *fileModel, transforms []PathTransform) (ArchiveDirOpts, error) {
+	excludeList := make([]string, len(model.Excludes.Elements()))
+
+	if !model.Excludes.IsNull() {
+		var elements []types.String
+		model.Excludes.ElementsAs(ctx, &elements, false)
+
+		for i, elem := range elements {
+			excludeList[i] = elem.ValueString()
+		}
+	}
+
+	var includeList, presets []string
+	model.Includes.ElementsAs(ctx, &includeList, false)
+	model.ExcludePresets.ElementsAs(ctx, &presets, false)
+
+	opts := ArchiveDirOpts{
+		TargetPrefix:    model.TargetPrefix.ValueString(),
+		StripComponents: int(model.StripComponents.ValueInt64()),
+		Flatten:         model.Flatten.ValueBool(),
+		Includes:        includeList,
+		Excludes:        presetExcludes(presets, excludeList),
+		ExcludesSyntax:  model.ExcludesSyntax.ValueString(),
+		PathTransforms:  transforms,
+		OnCollision:     model.OnCollision.ValueString(),
+	}
+
+	if !model.IgnoreFiles.IsNull() {
+		model.IgnoreFiles.ElementsAs(ctx, &opts.IgnoreFiles, false)
+	}
+
+	if err := setFileInfoExcludes(ctx, &opts, model.ExcludeLargerThan, model.ExcludeOlderThan, model.ExcludeFileTypes); err != nil {
+		return ArchiveDirOpts{}, err
+	}
+
+	if !model.ExcludeSymlinkDirectories.IsNull() {
+		opts.ExcludeSymlinkDirectories = model.ExcludeSymlinkDirectories.ValueBool()
+	}
+
+	opts.SymlinkMode = model.SymlinkMode.ValueString()
+	opts.IncludeDirectories = model.IncludeDirectories.ValueBool()
+
+	if !model.NodePruneDevDependencies.IsNull() {
+		opts.NodePruneDevDependencies = model.NodePruneDevDependencies.ValueBool()
+	}
+
+	return opts, nil
+}
+
+// sourceDirectories returns the source_directory blocks configured in model.
+func sourceDirectories(ctx context.Context, model *fileModel, transforms []PathTransform) ([]ArchiveDirSource, error) {
+	var elements []sourceDirectoryModel
+	model.SourceDirectories.ElementsAs(ctx, &elements, false)
+
+	dirs := make([]ArchiveDirSource, len(elements))
+	for i, elem := range elements {
+		var includes, excludes, presets, ignoreFiles []string
+		elem.Includes.ElementsAs(ctx, &includes, false)
+		elem.Excludes.ElementsAs(ctx, &excludes, false)
+		elem.ExcludePresets.ElementsAs(ctx, &presets, false)
+		elem.IgnoreFiles.ElementsAs(ctx, &ignoreFiles, false)
+
+		dirs[i] = ArchiveDirSource{
+			Path: elem.Path.ValueString(),
+			Opts: ArchiveDirOpts{
+				TargetPrefix:              elem.TargetPrefix.ValueString(),
+				StripComponents:           int(elem.StripComponents.ValueInt64()),
+				Flatten:                   elem.Flatten.ValueBool(),
+				Includes:                  includes,
+				Excludes:                  presetExcludes(presets, excludes),
+				ExcludesSyntax:            elem.ExcludesSyntax.ValueString(),
+				ExcludeSymlinkDirectories: elem.ExcludeSymlinkDirectories.ValueBool(),
+				SymlinkMode:               elem.SymlinkMode.ValueString(),
+				IncludeDirectories:        model.IncludeDirectories.ValueBool(),
+				IgnoreFiles:               ignoreFiles,
+				PathTransforms:            transforms,
+				OnCollision:               model.OnCollision.ValueString(),
+			},
+		}
+
+		if err := setFileInfoExcludes(ctx, &dirs[i].Opts, elem.ExcludeLargerThan, elem.ExcludeOlderThan, elem.ExcludeFileTypes); err != nil {
+			return nil, err
+		}
 	}
+
+	return dirs, nil
 }
 
-func archive(ctx context.Context, model fileModel) error {
-	archiveType := model.Type.ValueString()
-	outputPath := model.OutputPath.ValueString()
+// pathTransforms compiles the path_transform blocks of a configuration.
+func pathTransforms(ctx context.Context, list types.List) ([]PathTransform, error) {
+	var elements []pathTransformModel
+	list.ElementsAs(ctx, &elements, false)
 
-	archiver := getArchiver(archiveType, outputPath)
-	if archiver == nil {
-		return fmt.Errorf("archive type not supported: %s", archiveType)
+	transforms := make([]PathTransform, len(elements))
+	for i, elem := range elements {
+		match, err := regexp.Compile(elem.Match.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("invalid path_transform match %q: %s", elem.Match.ValueString(), err)
+		}
+
+		transforms[i] = PathTransform{Match: match, Replace: elem.Replace.ValueString()}
 	}
 
-	outputFileMode := model.OutputFileMode.ValueString()
-	if outputFileMode != "" {
-		archiver.SetOutputFileMode(outputFileMode)
+	return transforms, nil
+}
+
+// validateArchivePaths reports invalid path_transform patterns, target prefixes and targets and, once every value of
+// the configuration is known, the files which path_transform, strip_components or flatten would archive under the same
+// name. Other errors are left to the archive creation.
+func validateArchivePaths(ctx context.Context, config tfsdk.Config) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var model fileModel
+	diags.Append(config.Get(ctx, &model)...)
+	if diags.HasError() {
+		return diags
 	}
 
-	switch {
-	case !model.SourceDir.IsNull():
-		excludeList := make([]string, len(model.Excludes.Elements()))
+	var elements []pathTransformModel
+	model.PathTransforms.ElementsAs(ctx, &elements, false)
+
+	for i, elem := range elements {
+		if elem.Match.IsUnknown() {
+			continue
+		}
+
+		if _, err := regexp.Compile(elem.Match.ValueString()); err != nil {
+			diags.AddAttributeError(
+				fwpath.Root("path_transform").AtListIndex(i).AtName("match"),
+				"Invalid path transform",
+				fmt.Sprintf("invalid regular expression %q: %s", elem.Match.ValueString(), err),
+			)
+		}
+	}
+
+	diags.Append(validateTargetPaths(ctx, &model)...)
 
-		if !model.Excludes.IsNull() {
-			var elements []types.String
-			model.Excludes.ElementsAs(ctx, &elements, false)
+	if diags.HasError() || !config.Raw.IsFullyKnown() || !renamesFiles(ctx, &model) {
+		return diags
+	}
+
+	transforms, err := pathTransforms(ctx, model.PathTransforms)
+	if err != nil {
+		return diags
+	}
+
+	var collision *pathCollisionError
+
+	var sources []sourceModel
+	model.Source.ElementsAs(ctx, &sources, false)
+
+	content := make(map[string][]byte, len(sources))
+	for _, elem := range sources {
+		content[elem.Filename.ValueString()] = nil
+	}
+	if _, err := transformContent(content, transforms, model.OnCollision.ValueString()); err != nil {
+		diags.AddError("Conflicting archive paths", err.Error())
+	}
+
+	if !model.SourceDir.IsNull() {
+		if opts, err := sourceDirOpts(ctx, &model, transforms); err == nil {
+			if _, err := walkDir(model.SourceDir.ValueString(), opts); errors.As(err, &collision) {
+				diags.AddError("Conflicting archive paths", err.Error())
+			}
+		}
+	}
 
-			for i, elem := range elements {
-				excludeList[i] = elem.ValueString()
+	if len(model.SourceDirectories.Elements()) > 0 {
+		if dirs, err := sourceDirectories(ctx, &model, transforms); err == nil {
+			if _, err := walkDirs(dirs); errors.As(err, &collision) {
+				diags.AddError("Conflicting archive paths", err.Error())
 			}
 		}
+	}
+
+	return diags
+}
+
+// validateArchiveType reports the attributes which the type of the archive does not record, such as the owners of
+// files in zip archives, rather than ignoring them.
+func validateArchiveType(ctx context.Context, config tfsdk.Config) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var model fileModel
+	diags.Append(config.Get(ctx, &model)...)
+	if diags.HasError() || model.Type.ValueString() != "zip" {
+		return diags
+	}
+
+	for _, attribute := range tarOnlyAttributes(&model) {
+		diags.AddAttributeError(
+			fwpath.Root(attribute),
+			"Unsupported attribute",
+			fmt.Sprintf("%s is not supported by zip archives", attribute),
+		)
+	}
+
+	return diags
+}
+
+// tarOnlyAttributes returns the attributes configured in model which only tar archives record.
+func tarOnlyAttributes(model *fileModel) []string {
+	var attributes []string
+
+	if !model.TarFormat.IsNull() {
+		attributes = append(attributes, "tar_format")
+	}
+	if !model.Owner.IsNull() {
+		attributes = append(attributes, "owner")
+	}
+	if !model.Group.IsNull() {
+		attributes = append(attributes, "group")
+	}
+	if model.OwnerRules.IsUnknown() || len(model.OwnerRules.Elements()) > 0 {
+		attributes = append(attributes, "owner_rule")
+	}
+	if model.PreserveXattrs.IsUnknown() || model.PreserveXattrs.ValueBool() {
+		attributes = append(attributes, "preserve_xattrs")
+	}
+	if !model.XattrNamespaces.IsNull() {
+		attributes = append(attributes, "xattr_namespaces")
+	}
+	if model.PreserveHardlinks.IsUnknown() || model.PreserveHardlinks.ValueBool() {
+		attributes = append(attributes, "preserve_hardlinks")
+	}
+
+	return attributes
+}
+
+// validateTargetPaths reports the target prefixes and targets which are absolute or outside of the archive, such as
+// "../../etc".
+func validateTargetPaths(ctx context.Context, model *fileModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	validate := func(p fwpath.Path, value types.String, clean func(string) (string, error)) {
+		if value.IsNull() || value.IsUnknown() {
+			return
+		}
 
-		opts := ArchiveDirOpts{
-			Excludes: excludeList,
+		if _, err := clean(value.ValueString()); err != nil {
+			diags.AddAttributeError(p, "Invalid archive path", err.Error())
 		}
+	}
+
+	validate(fwpath.Root("target_prefix"), model.TargetPrefix, cleanArchivePrefix)
+	validate(fwpath.Root("source_file_target"), model.SourceFileTarget, cleanArchivePath)
+
+	var dirs []sourceDirectoryModel
+	model.SourceDirectories.ElementsAs(ctx, &dirs, false)
+	for i, elem := range dirs {
+		validate(fwpath.Root("source_directory").AtListIndex(i).AtName("target_prefix"), elem.TargetPrefix,
+			cleanArchivePrefix)
+	}
+
+	var archives []sourceArchiveModel
+	model.SourceArchives.ElementsAs(ctx, &archives, false)
+	for i, elem := range archives {
+		validate(fwpath.Root("source_archive").AtListIndex(i).AtName("target_prefix"), elem.TargetPrefix,
+			cleanArchivePrefix)
+	}
+
+	var wheels []pythonWheelsModel
+	model.PythonWheels.ElementsAs(ctx, &wheels, false)
+	for i, elem := range wheels {
+		validate(fwpath.Root("python_wheels").AtListIndex(i).AtName("target_prefix"), elem.TargetPrefix,
+			cleanArchivePrefix)
+	}
+
+	var urls []sourceURLModel
+	model.SourceURLs.ElementsAs(ctx, &urls, false)
+	for i, elem := range urls {
+		validate(fwpath.Root("source_url").AtListIndex(i).AtName("target"), elem.Target, cleanArchivePath)
+	}
 
-		if !model.ExcludeSymlinkDirectories.IsNull() {
-			opts.ExcludeSymlinkDirectories = model.ExcludeSymlinkDirectories.ValueBool()
+	var entries []entryModel
+	model.Entries.ElementsAs(ctx, &entries, false)
+	for i, elem := range entries {
+		// The target of a directory is the directory its files are archived below.
+		clean := cleanArchivePath
+		if !elem.Directory.IsNull() {
+			clean = cleanArchivePrefix
 		}
+		validate(fwpath.Root("entry").AtListIndex(i).AtName("target"), elem.Target, clean)
+	}
+
+	return diags
+}
 
-		if err := archiver.ArchiveDir(model.SourceDir.ValueString(), opts); err != nil {
-			return fmt.Errorf("error archiving directory: %s", err)
+// renamesFiles reports whether model configures path_transform, strip_components or flatten, which can archive
+// several files under the same name.
+func renamesFiles(ctx context.Context, model *fileModel) bool {
+	if len(model.PathTransforms.Elements()) > 0 || model.StripComponents.ValueInt64() > 0 || model.Flatten.ValueBool() {
+		return true
+	}
+
+	var dirs []sourceDirectoryModel
+	model.SourceDirectories.ElementsAs(ctx, &dirs, false)
+
+	for _, dir := range dirs {
+		if dir.StripComponents.ValueInt64() > 0 || dir.Flatten.ValueBool() {
+			return true
 		}
-	case !model.SourceFile.IsNull():
-		if err := archiver.ArchiveFile(model.SourceFile.ValueString()); err != nil {
-			return fmt.Errorf("error archiving file: %s", err)
+	}
+
+	return false
+}
+
+// fileModeOpts returns the modes of the archived files configured in model.
+func fileModeOpts(ctx context.Context, model *fileModel) (FileModeOpts, error) {
+	opts := FileModeOpts{
+		AutoExecutable: model.AutoExecutable.ValueBool(),
+	}
+
+	if !model.DirectoryMode.IsNull() {
+		mode, err := parseFileMode(model.DirectoryMode.ValueString())
+		if err != nil {
+			return FileModeOpts{}, err
 		}
-	case !model.SourceContentFilename.IsNull():
-		content := model.SourceContent.ValueString()
+		opts.DirectoryMode = mode
+	}
 
-		if err := archiver.ArchiveContent([]byte(content), model.SourceContentFilename.ValueString()); err != nil {
-			return fmt.Errorf("error archiving content: %s", err)
+	var elements []fileModeRuleModel
+	model.FileModeRules.ElementsAs(ctx, &elements, false)
+
+	for _, elem := range elements {
+		mode, err := parseFileMode(elem.Mode.ValueString())
+		if err != nil {
+			return FileModeOpts{}, err
 		}
-	case !model.Source.IsNull():
-		content := make(map[string][]byte)
 
-		var elements []sourceModel
-		model.Source.ElementsAs(ctx, &elements, false)
+		opts.Rules = append(opts.Rules, FileModeRule{Pattern: elem.Pattern.ValueString(), Mode: mode})
+	}
 
-		for _, elem := range elements {
-			content[elem.Filename.ValueString()] = []byte(elem.Content.ValueString())
+	return opts, nil
+}
+
+// ownerOpts returns the owners of the archived files configured in model.
+func ownerOpts(ctx context.Context, model *fileModel) (OwnerOpts, error) {
+	var opts OwnerOpts
+
+	var err error
+	if !model.Owner.IsNull() {
+		if opts.Owner, err = parseOwner(model.Owner.ValueString()); err != nil {
+			return OwnerOpts{}, err
+		}
+	}
+	if !model.Group.IsNull() {
+		if opts.Group, err = parseOwner(model.Group.ValueString()); err != nil {
+			return OwnerOpts{}, err
+		}
+	}
+
+	var elements []ownerRuleModel
+	model.OwnerRules.ElementsAs(ctx, &elements, false)
+
+	for _, elem := range elements {
+		rule := OwnerRule{Pattern: elem.Pattern.ValueString()}
+
+		if !elem.Owner.IsNull() {
+			owner, err := parseOwner(elem.Owner.ValueString())
+			if err != nil {
+				return OwnerOpts{}, err
+			}
+			rule.Owner = &owner
+		}
+		if !elem.Group.IsNull() {
+			group, err := parseOwner(elem.Group.ValueString())
+			if err != nil {
+				return OwnerOpts{}, err
+			}
+			rule.Group = &group
 		}
 
-		if err := archiver.ArchiveMultiple(content); err != nil {
-			return fmt.Errorf("error archiving content: %s", err)
+		opts.Rules = append(opts.Rules, rule)
+	}
+
+	return opts, nil
+}
+
+// setFileInfoExcludes sets the options of opts excluding files by their size, modification time or type.
+func setFileInfoExcludes(ctx context.Context, opts *ArchiveDirOpts, largerThan types.Int64, olderThan types.String, fileTypes types.Set) error {
+	opts.ExcludeLargerThan = largerThan.ValueInt64()
+
+	if !olderThan.IsNull() {
+		t, err := parseExcludeOlderThan(olderThan.ValueString(), time.Now())
+		if err != nil {
+			return err
 		}
+		opts.ExcludeOlderThan = t
 	}
 
+	fileTypes.ElementsAs(ctx, &opts.ExcludeFileTypes, false)
+
 	return nil
 }
 
@@ -285,7 +1715,7 @@ func (d *archiveFileDataSource) Read(ctx context.Context, req datasource.ReadReq
 		}
 	}
 
-	if err := archive(ctx, model); err != nil {
+	if err := archive(ctx, &model); err != nil {
 		resp.Diagnostics.AddError(
 			"Archive creation error",
 			fmt.Sprintf("error creating archive: %s", err),
@@ -293,7 +1723,8 @@ func (d *archiveFileDataSource) Read(ctx context.Context, req datasource.ReadReq
 		return
 	}
 
-	// Generate archived file stats
+	// The size and checksums are those of the whole archive, before it is split, as the volumes of split zip
+	// archives record offsets relative to each volume rather than being a plain split of the archive.
 	fi, err := os.Stat(outputPath)
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -310,7 +1741,37 @@ func (d *archiveFileDataSource) Read(ctx context.Context, req datasource.ReadReq
 			"Hash generation error",
 			fmt.Sprintf("error generating checksums: %s", err),
 		)
+		return
 	}
+
+	model.OutputParts = types.ListNull(types.ObjectType{AttrTypes: outputPartAttrTypes})
+
+	if model.SplitSize.IsNull() {
+		// Remove the volumes of a previous run which set split_size.
+		if err := removeStaleParts(model.Type.ValueString(), outputPath); err != nil {
+			resp.Diagnostics.AddError(
+				"Archive split error",
+				fmt.Sprintf("error splitting archive: %s", err),
+			)
+			return
+		}
+	} else {
+		outputParts, err := splitArchive(model.Type.ValueString(), outputPath, model.SplitSize.ValueInt64())
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Archive split error",
+				fmt.Sprintf("error splitting archive: %s", err),
+			)
+			return
+		}
+
+		model.OutputParts, diags = outputPartsValue(ctx, outputParts)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	model.OutputMd5 = types.StringValue(checksums.md5Hex)
 	model.OutputSha = types.StringValue(checksums.sha1Hex)
 	model.OutputSha256 = types.StringValue(checksums.sha256Hex)
@@ -330,17 +1791,56 @@ func (d *archiveFileDataSource) Metadata(_ context.Context, req datasource.Metad
 
 type fileModel struct {
 	ID                        types.String `tfsdk:"id"`
-	Source                    types.Set    `tfsdk:"source"` // sourceModel
+	Source                    types.Set    `tfsdk:"source"`           // sourceModel
+	PythonWheels              types.List   `tfsdk:"python_wheels"`    // pythonWheelsModel
+	SourceDirectories         types.List   `tfsdk:"source_directory"` // sourceDirectoryModel
+	SourceGit                 types.List   `tfsdk:"source_git"`       // sourceGitModel
+	SourceArchives            types.List   `tfsdk:"source_archive"`   // sourceArchiveModel
+	SourceURLs                types.List   `tfsdk:"source_url"`       // sourceURLModel
+	Entries                   types.List   `tfsdk:"entry"`            // entryModel
+	PathTransforms            types.List   `tfsdk:"path_transform"`   // pathTransformModel
+	FileModeRules             types.List   `tfsdk:"file_mode_rule"`   // fileModeRuleModel
+	OwnerRules                types.List   `tfsdk:"owner_rule"`       // ownerRuleModel
 	Type                      types.String `tfsdk:"type"`
 	SourceContent             types.String `tfsdk:"source_content"`
+	SourceContentBase64       types.String `tfsdk:"source_content_base64"`
 	SourceContentFilename     types.String `tfsdk:"source_content_filename"`
 	SourceFile                types.String `tfsdk:"source_file"`
+	SourceFileTarget          types.String `tfsdk:"source_file_target"`
 	SourceDir                 types.String `tfsdk:"source_dir"`
-	Excludes                  types.Set    `tfsdk:"excludes"`
+	TargetPrefix              types.String `tfsdk:"target_prefix"`
+	StripComponents           types.Int64  `tfsdk:"strip_components"`
+	Flatten                   types.Bool   `tfsdk:"flatten"`
+	OnCollision               types.String `tfsdk:"on_collision"`
+	Includes                  types.Set    `tfsdk:"includes"`
+	Excludes                  types.List   `tfsdk:"excludes"`
+	ExcludesSyntax            types.String `tfsdk:"excludes_syntax"`
+	IgnoreFiles               types.List   `tfsdk:"ignore_files"`
+	IgnoreFilesUsed           types.List   `tfsdk:"ignore_files_used"`
+	SourceGitCommit           types.String `tfsdk:"source_git_commit"`
+	ExcludePresets            types.Set    `tfsdk:"exclude_presets"`
+	ExcludeLargerThan         types.Int64  `tfsdk:"exclude_larger_than"`
+	ExcludeOlderThan          types.String `tfsdk:"exclude_older_than"`
+	ExcludeFileTypes          types.Set    `tfsdk:"exclude_file_types"`
 	ExcludeSymlinkDirectories types.Bool   `tfsdk:"exclude_symlink_directories"`
+	SymlinkMode               types.String `tfsdk:"symlink_mode"`
+	IncludeDirectories        types.Bool   `tfsdk:"include_directories"`
+	NodePruneDevDependencies  types.Bool   `tfsdk:"node_prune_dev_dependencies"`
 	OutputPath                types.String `tfsdk:"output_path"`
 	OutputSize                types.Int64  `tfsdk:"output_size"`
+	SplitSize                 types.Int64  `tfsdk:"split_size"`
+	OutputParts               types.List   `tfsdk:"output_parts"` // outputPartModel
 	OutputFileMode            types.String `tfsdk:"output_file_mode"`
+	DirectoryMode             types.String `tfsdk:"directory_mode"`
+	AutoExecutable            types.Bool   `tfsdk:"auto_executable"`
+	MtimeMode                 types.String `tfsdk:"mtime_mode"`
+	Mtime                     types.String `tfsdk:"mtime"`
+	Owner                     types.String `tfsdk:"owner"`
+	Group                     types.String `tfsdk:"group"`
+	TarFormat                 types.String `tfsdk:"tar_format"`
+	PreserveXattrs            types.Bool   `tfsdk:"preserve_xattrs"`
+	XattrNamespaces           types.Set    `tfsdk:"xattr_namespaces"`
+	PreserveHardlinks         types.Bool   `tfsdk:"preserve_hardlinks"`
 	OutputMd5                 types.String `tfsdk:"output_md5"`
 	OutputSha                 types.String `tfsdk:"output_sha"`
 	OutputSha256              types.String `tfsdk:"output_sha256"`
@@ -350,8 +1850,156 @@ type fileModel struct {
 }
 
 type sourceModel struct {
-	Content  types.String `tfsdk:"content"`
-	Filename types.String `tfsdk:"filename"`
+	Content       types.String `tfsdk:"content"`
+	ContentBase64 types.String `tfsdk:"content_base64"`
+	Filename      types.String `tfsdk:"filename"`
+}
+
+type pythonWheelsModel struct {
+	Paths             types.List   `tfsdk:"paths"`
+	TargetPrefix      types.String `tfsdk:"target_prefix"`
+	SkipRecordRewrite types.Bool   `tfsdk:"skip_record_rewrite"`
+}
+
+type sourceDirectoryModel struct {
+	Path                      types.String `tfsdk:"path"`
+	TargetPrefix              types.String `tfsdk:"target_prefix"`
+	StripComponents           types.Int64  `tfsdk:"strip_components"`
+	Flatten                   types.Bool   `tfsdk:"flatten"`
+	Includes                  types.Set    `tfsdk:"includes"`
+	Excludes                  types.List   `tfsdk:"excludes"`
+	ExcludesSyntax            types.String `tfsdk:"excludes_syntax"`
+	IgnoreFiles               types.List   `tfsdk:"ignore_files"`
+	ExcludePresets            types.Set    `tfsdk:"exclude_presets"`
+	ExcludeLargerThan         types.Int64  `tfsdk:"exclude_larger_than"`
+	ExcludeOlderThan          types.String `tfsdk:"exclude_older_than"`
+	ExcludeFileTypes          types.Set    `tfsdk:"exclude_file_types"`
+	ExcludeSymlinkDirectories types.Bool   `tfsdk:"exclude_symlink_directories"`
+	SymlinkMode               types.String `tfsdk:"symlink_mode"`
+}
+
+type pathTransformModel struct {
+	Match   types.String `tfsdk:"match"`
+	Replace types.String `tfsdk:"replace"`
+}
+
+type fileModeRuleModel struct {
+	Pattern types.String `tfsdk:"pattern"`
+	Mode    types.String `tfsdk:"mode"`
+}
+
+type ownerRuleModel struct {
+	Pattern types.String `tfsdk:"pattern"`
+	Owner   types.String `tfsdk:"owner"`
+	Group   types.String `tfsdk:"group"`
+}
+
+type sourceGitModel struct {
+	RepositoryPath types.String `tfsdk:"repository_path"`
+	Ref            types.String `tfsdk:"ref"`
+	Subdirectory   types.String `tfsdk:"subdirectory"`
+}
+
+type sourceArchiveModel struct {
+	Path            types.String `tfsdk:"path"`
+	Excludes        types.Set    `tfsdk:"excludes"`
+	StripComponents types.Int64  `tfsdk:"strip_components"`
+	TargetPrefix    types.String `tfsdk:"target_prefix"`
+}
+
+type sourceURLModel struct {
+	URL     types.String `tfsdk:"url"`
+	SHA256  types.String `tfsdk:"sha256"`
+	Target  types.String `tfsdk:"target"`
+	Mode    types.String `tfsdk:"mode"`
+	Headers types.Map    `tfsdk:"headers"`
+}
+
+type entryModel struct {
+	Content       types.String `tfsdk:"content"`
+	ContentBase64 types.String `tfsdk:"content_base64"`
+	File          types.String `tfsdk:"file"`
+	Directory     types.String `tfsdk:"directory"`
+	Target        types.String `tfsdk:"target"`
+	Mode          types.String `tfsdk:"mode"`
+	Mtime         types.String `tfsdk:"mtime"`
+}
+
+// archiveEntries returns the entries added to the archive by an entry block.
+func (e entryModel) archiveEntries() ([]ArchiveEntry, error) {
+	var mode *os.FileMode
+	if !e.Mode.IsNull() {
+		m, err := parseFileMode(e.Mode.ValueString())
+		if err != nil {
+			return nil, err
+		}
+		mode = &m
+	}
+
+	var mtime time.Time
+	if !e.Mtime.IsNull() {
+		var err error
+		mtime, err = parseMtime(e.Mtime.ValueString())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	target := e.Target.ValueString()
+	if e.Directory.IsNull() && target != "" {
+		var err error
+		target, err = cleanArchivePath(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target: %s", err)
+		}
+	}
+
+	var entries []ArchiveEntry
+	switch {
+	case !e.Content.IsNull():
+		entries = []ArchiveEntry{{
+			Name:    target,
+			Content: []byte(e.Content.ValueString()),
+		}}
+	case !e.ContentBase64.IsNull():
+		content, err := base64.StdEncoding.DecodeString(e.ContentBase64.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("could not decode content_base64: %s", err)
+		}
+
+		entries = []ArchiveEntry{{
+			Name:    target,
+			Content: content,
+		}}
+	case !e.File.IsNull():
+		entry, err := fileEntry(e.File.ValueString())
+		if err != nil {
+			return nil, err
+		}
+
+		if target != "" {
+			entry.Name = target
+		}
+
+		entries = []ArchiveEntry{entry}
+	case !e.Directory.IsNull():
+		var err error
+		entries, err = walkDir(e.Directory.ValueString(), ArchiveDirOpts{
+			TargetPrefix: target,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range entries {
+		if mode != nil {
+			entries[i].setMode(*mode)
+		}
+		entries[i].ModTime = mtime
+	}
+
+	return entries, nil
 }
 
 type fileChecksums struct {
@@ -363,27 +2011,88 @@ type fileChecksums struct {
 	sha512Base64 string
 }
 
+type outputPartModel struct {
+	Path   types.String `tfsdk:"path"`
+	Size   types.Int64  `tfsdk:"size"`
+	Sha256 types.String `tfsdk:"sha256"`
+}
+
+var outputPartAttrTypes = map[string]attr.Type{
+	"path":   types.StringType,
+	"size":   types.Int64Type,
+	"sha256": types.StringType,
+}
+
+func outputPartsValue(ctx context.Context, parts []string) (types.List, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	elemType := types.ObjectType{AttrTypes: outputPartAttrTypes}
+
+	models := make([]outputPartModel, 0, len(parts))
+	for _, part := range parts {
+		fi, err := os.Stat(part)
+		if err != nil {
+			diags.AddError(
+				"Archive output error",
+				fmt.Sprintf("error reading output: %s", err),
+			)
+			return types.ListNull(elemType), diags
+		}
+
+		checksums, err := genFileChecksums(part)
+		if err != nil {
+			diags.AddError(
+				"Hash generation error",
+				fmt.Sprintf("error generating checksums: %s", err),
+			)
+			return types.ListNull(elemType), diags
+		}
+
+		models = append(models, outputPartModel{
+			Path:   types.StringValue(part),
+			Size:   types.Int64Value(fi.Size()),
+			Sha256: types.StringValue(checksums.sha256Hex),
+		})
+	}
+
+	return types.ListValueFrom(ctx, elemType, models)
+}
+
+// genFileChecksums returns the checksums of the contents of filename, which is read as a stream as archives can be
+// large.
 func genFileChecksums(filename string) (fileChecksums, error) {
 	var checksums fileChecksums
 
-	data, err := os.ReadFile(filename)
-	if err != nil {
+	md5Hash := md5.New()
+	sha1Hash := sha1.New()
+	sha256Hash := sha256.New()
+	sha512Hash := sha512.New()
+	w := io.MultiWriter(md5Hash, sha1Hash, sha256Hash, sha512Hash)
+
+	if err := copyFileTo(w, filename); err != nil {
 		return checksums, fmt.Errorf("could not compute file '%s' checksum: %s", filename, err)
 	}
 
-	md5Sum := md5.Sum(data)
-	checksums.md5Hex = hex.EncodeToString(md5Sum[:])
+	checksums.md5Hex = hex.EncodeToString(md5Hash.Sum(nil))
+	checksums.sha1Hex = hex.EncodeToString(sha1Hash.Sum(nil))
 
-	sha1Sum := sha1.Sum(data)
-	checksums.sha1Hex = hex.EncodeToString(sha1Sum[:])
+	sha256Sum := sha256Hash.Sum(nil)
+	checksums.sha256Hex = hex.EncodeToString(sha256Sum)
+	checksums.sha256Base64 = base64.StdEncoding.EncodeToString(sha256Sum)
 
-	sha256Sum := sha256.Sum256(data)
-	checksums.sha256Hex = hex.EncodeToString(sha256Sum[:])
-	checksums.sha256Base64 = base64.StdEncoding.EncodeToString(sha256Sum[:])
-
-	sha512Sum := sha512.Sum512(data)
-	checksums.sha512Hex = hex.EncodeToString(sha512Sum[:])
-	checksums.sha512Base64 = base64.StdEncoding.EncodeToString(sha512Sum[:])
+	sha512Sum := sha512Hash.Sum(nil)
+	checksums.sha512Hex = hex.EncodeToString(sha512Sum)
+	checksums.sha512Base64 = base64.StdEncoding.EncodeToString(sha512Sum)
 
 	return checksums, nil
 }
+
+func copyFileTo(w io.Writer, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -7,7 +7,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	r "github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/terraform"
@@ -236,6 +239,524 @@ func TestDataSource_UpgradeFromVersion2_2_0_SourceConfig(t *testing.T) {
 	})
 }
 
+func TestDataSource_PythonWheels(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_python_wheels.zip")
+	wheel := createTestWheel(t, "six-1.16.0-py2.py3-none-any.whl", "six-1.16.0", map[string]string{
+		"six.py": "# six",
+	})
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+data "archive_file" "foo" {
+  type = "zip"
+  source {
+    filename = "handler.py"
+    content  = "import six"
+  }
+  python_wheels {
+    paths         = ["%s"]
+    target_prefix = "python"
+  }
+  output_path = "%s"
+}
+`, filepath.ToSlash(wheel), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("data.archive_file.foo", "output_path", func(value string) error {
+					wheelMetadata := "Wheel-Version: 1.0\nRoot-Is-Purelib: true\n"
+					record := fmt.Sprintf("six-1.16.0.dist-info/WHEEL,sha256=%s,%d\nsix.py,sha256=%s,5\nsix-1.16.0.dist-info/RECORD,,\n",
+						recordHash(wheelMetadata), len(wheelMetadata), recordHash("# six"))
+
+					ensureContents(t, value, map[string][]byte{
+						"handler.py":                         []byte("import six"),
+						"python/six.py":                      []byte("# six"),
+						"python/six-1.16.0.dist-info/WHEEL":  []byte(wheelMetadata),
+						"python/six-1.16.0.dist-info/RECORD": []byte(record),
+					})
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestDataSource_NodePruneDevDependencies(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_node_prune.zip")
+	project := createTestNodeProject(t)
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+data "archive_file" "foo" {
+  type                        = "zip"
+  source_dir                  = "%s"
+  excludes                    = ["package-lock.json"]
+  node_prune_dev_dependencies = true
+  output_path                 = "%s"
+}
+`, filepath.ToSlash(project), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("data.archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"index.js":                            []byte("require('lodash')"),
+						"node_modules/lodash/index.js":        []byte("lodash"),
+						"node_modules/@smithy/types/index.js": []byte("smithy"),
+					})
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestDataSource_SplitSize(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "tar_file_acc_test_split_size.tar.gz")
+	src := filepath.Join(td, "file.bin")
+	if err := os.WriteFile(src, randomContent(200*1024), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := func(splitSize string) string {
+		return fmt.Sprintf(`
+data "archive_file" "foo" {
+  type        = "tar.gz"
+  source_file = "%s"
+  split_size  = %s
+  output_path = "%s"
+}
+`, filepath.ToSlash(src), splitSize, filepath.ToSlash(f))
+	}
+
+	var outputSha256 string
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: config("65536"),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.archive_file.foo", "output_parts.#", "4"),
+					r.TestCheckResourceAttr("data.archive_file.foo", "output_parts.0.path", filepath.ToSlash(f)+".001"),
+					r.TestCheckResourceAttr("data.archive_file.foo", "output_parts.0.size", "65536"),
+					r.TestCheckResourceAttr("data.archive_file.foo", "output_parts.3.path", filepath.ToSlash(f)+".004"),
+					testExtractResourceAttr("data.archive_file.foo", "output_sha256", &outputSha256),
+				),
+			},
+			{
+				Config: config("null"),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckNoResourceAttr("data.archive_file.foo", "output_parts"),
+					r.TestCheckResourceAttrPtr("data.archive_file.foo", "output_sha256", &outputSha256),
+					func(_ *terraform.State) error {
+						if _, err := os.Stat(f + ".001"); !os.IsNotExist(err) {
+							return fmt.Errorf("expected the volumes to be removed, got: %v", err)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_SourceDirectory(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_source_directory.zip")
+	src := filepath.Join(td, "src")
+	writeTestFiles(t, src, map[string]string{
+		"index.js":      "exports.handler = () => {}",
+		"index.test.js": "test()",
+	})
+	app := filepath.ToSlash(createTestIgnoreFilesDir(t))
+
+	contents := map[string][]byte{
+		"index.js": []byte("exports.handler = () => {}"),
+	}
+	for name, content := range testIgnoreFilesContents {
+		contents["app/"+name] = content
+	}
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+data "archive_file" "foo" {
+  type = "zip"
+  source_directory {
+    path     = "%s"
+    excludes = ["*.test.js"]
+  }
+  source_directory {
+    path          = "%s"
+    target_prefix = "app"
+    ignore_files  = [".gitignore", ".archiveignore"]
+  }
+  output_path = "%s"
+}
+`, filepath.ToSlash(src), app, filepath.ToSlash(f)),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrWith("data.archive_file.foo", "output_path", func(value string) error {
+						ensureContents(t, value, contents)
+						return nil
+					}),
+					r.TestCheckResourceAttr("data.archive_file.foo", "ignore_files_used.#", "3"),
+					r.TestCheckResourceAttr("data.archive_file.foo", "ignore_files_used.0", app+"/.gitignore"),
+					r.TestCheckResourceAttr("data.archive_file.foo", "ignore_files_used.1", app+"/.archiveignore"),
+					r.TestCheckResourceAttr("data.archive_file.foo", "ignore_files_used.2", app+"/src/.gitignore"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_Entry(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_entry.zip")
+	writeTestFiles(t, td, map[string]string{
+		"handler.py":       "def handler(): pass",
+		"templates/a.html": "<a>",
+	})
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+data "archive_file" "foo" {
+  type       = "zip"
+  source_dir = "%[1]s/templates"
+  entry {
+    file   = "%[1]s/handler.py"
+    target = "app/main.py"
+  }
+  entry {
+    directory = "%[1]s/templates"
+    target    = "app/templates"
+  }
+  entry {
+    content_base64 = "AAEC"
+    target         = "app/data.bin"
+    mode           = "0600"
+  }
+  entry {
+    content = "1.0.0"
+    target  = "VERSION"
+  }
+  output_path = "%[2]s"
+}
+`, filepath.ToSlash(td), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("data.archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"VERSION":              []byte("1.0.0"),
+						"a.html":               []byte("<a>"),
+						"app/main.py":          []byte("def handler(): pass"),
+						"app/templates/a.html": []byte("<a>"),
+						"app/data.bin":         {0, 1, 2},
+					})
+					ensureFileModes(t, value, map[string]os.FileMode{
+						"app/data.bin": 0600,
+					})
+					return nil
+				}),
+			},
+			{
+				Config: fmt.Sprintf(`
+data "archive_file" "foo" {
+  type       = "zip"
+  source_dir = "%[1]s/templates"
+  entry {
+    content = "<b>"
+    target  = "a.html"
+  }
+  output_path = "%[2]s"
+}
+`, filepath.ToSlash(td), filepath.ToSlash(f)),
+				ExpectError: regexp.MustCompile(`a.html \(from source_dir and entry 1\)`),
+			},
+		},
+	})
+}
+
+func TestDataSource_SourceGit(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_source_git.zip")
+	repo := createTestGitRepository(t)
+
+	config := func(ref string) string {
+		return fmt.Sprintf(`
+data "archive_file" "foo" {
+  type        = "zip"
+  output_path = "%s"
+
+  source_git {
+    repository_path = "%s"
+    ref             = %s
+    subdirectory    = "src"
+  }
+}
+`, filepath.ToSlash(f), filepath.ToSlash(repo), ref)
+	}
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: config(`"v1-annotated"`),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrWith("data.archive_file.foo", "output_path", func(value string) error {
+						ensureContents(t, value, map[string][]byte{
+							"main.py": []byte(testGitLargeFile("v1")),
+						})
+						return nil
+					}),
+					r.TestCheckResourceAttr("data.archive_file.foo", "source_git_commit", runGit(t, repo, "rev-parse", "v1^{commit}")),
+				),
+			},
+			{
+				Config: config("null"),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrWith("data.archive_file.foo", "output_path", func(value string) error {
+						ensureContents(t, value, map[string][]byte{
+							"main.py":     []byte(testGitLargeFile("v2")),
+							"pkg/util.py": []byte("util"),
+						})
+						return nil
+					}),
+					r.TestCheckResourceAttr("data.archive_file.foo", "source_git_commit", runGit(t, repo, "rev-parse", "HEAD")),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_SourceArchive(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_source_archive.zip")
+	sourceTar := createTestSourceTar(t, filepath.Join(td, "source.tar.gz"), true)
+	sourceZip := createTestSourceZip(t, filepath.Join(td, "source.zip"))
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+data "archive_file" "foo" {
+  type        = "zip"
+  output_path = "%s"
+
+  source_archive {
+    path             = "%s"
+    excludes         = ["docs", "*.txt"]
+    strip_components = 1
+  }
+
+  source_archive {
+    path             = "%s"
+    excludes         = ["docs", "*.txt", "bin"]
+    strip_components = 1
+    target_prefix    = "copy"
+  }
+}
+`, filepath.ToSlash(f), filepath.ToSlash(sourceTar), filepath.ToSlash(sourceZip)),
+				Check: r.TestCheckResourceAttrWith("data.archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"README.md":        []byte("readme"),
+						"bin/run.sh":       []byte("#!/bin/sh\necho run\n"),
+						"lib/main.py":      []byte("main"),
+						"copy/README.md":   []byte("readme"),
+						"copy/lib/main.py": []byte("main"),
+					})
+					ensureFileModes(t, value, map[string]os.FileMode{
+						"bin/run.sh": 0755,
+					})
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestDataSource_SourceURL(t *testing.T) {
+	td := t.TempDir()
+	t.Setenv(sourceURLCacheDirEnv, filepath.Join(td, "cache"))
+
+	f := filepath.Join(td, "zip_file_acc_test_source_url.zip")
+	server, requests := newTestSourceURLServer(t)
+
+	config := func(sha256 string) string {
+		return fmt.Sprintf(`
+data "archive_file" "foo" {
+  type        = "zip"
+  output_path = "%s"
+
+  source_url {
+    url     = "%s/ca.pem"
+    sha256  = "%s"
+    target  = "certs/ca.pem"
+    mode    = "0600"
+    headers = {
+      Authorization = "Bearer token"
+    }
+  }
+}
+`, filepath.ToSlash(f), server.URL, sha256)
+	}
+
+	r.Test(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: config(testSourceURLSHA256),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrWith("data.archive_file.foo", "output_path", func(value string) error {
+						ensureContents(t, value, map[string][]byte{
+							"certs/ca.pem": []byte(testSourceURLContent),
+						})
+						ensureFileModes(t, value, map[string]os.FileMode{
+							"certs/ca.pem": 0600,
+						})
+						return nil
+					}),
+					func(*terraform.State) error {
+						// Every read of the data source after the first one reads the file from the cache.
+						if got := requests.Load(); got != 1 {
+							return fmt.Errorf("got %d requests, want 1", got)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config:      config(strings.Repeat("0", 64)),
+				ExpectError: regexp.MustCompile(`checksum mismatch for`),
+			},
+		},
+	})
+}
+
+func TestDataSource_MtimeMode(t *testing.T) {
+	td := t.TempDir()
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	f := filepath.Join(td, "tar_file_acc_test_mtime_mode.tar.gz")
+	g := filepath.Join(td, "tar_file_acc_test_mtime_mode_fixed.tar.gz")
+	z := filepath.Join(td, "zip_file_acc_test_mtime_mode.zip")
+	dir := filepath.Join(td, "src")
+	writeTestFiles(t, dir, map[string]string{
+		"main.py":    "main",
+		"lib/app.py": "app",
+	})
+
+	epoch := time.Unix(1700000000, 0)
+	fixed := time.Date(1975, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ensureModTimes := func(mtime time.Time) func(string) error {
+		return func(value string) error {
+			for name, header := range tarHeaders(t, value) {
+				if !header.ModTime.Equal(mtime) {
+					return fmt.Errorf("expected mtime %s for %s, got %s", mtime, name, header.ModTime)
+				}
+			}
+			return nil
+		}
+	}
+
+	r.Test(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+data "archive_file" "epoch" {
+  type        = "tar.gz"
+  source_dir  = "%[1]s"
+  output_path = "%[2]s"
+  mtime_mode  = "source_date_epoch"
+}
+
+data "archive_file" "fixed" {
+  type        = "tar.gz"
+  source_dir  = "%[1]s"
+  output_path = "%[3]s"
+  mtime_mode  = "fixed"
+  mtime       = "1975-01-01T00:00:00Z"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f), filepath.ToSlash(g)),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrWith("data.archive_file.epoch", "output_path", ensureModTimes(epoch)),
+					r.TestCheckResourceAttrWith("data.archive_file.fixed", "output_path", ensureModTimes(fixed)),
+				),
+			},
+			{
+				Config: fmt.Sprintf(`
+data "archive_file" "fixed" {
+  type        = "zip"
+  source_dir  = "%s"
+  output_path = "%s"
+  mtime_mode  = "fixed"
+  mtime       = "1975-01-01T00:00:00Z"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(z)),
+				ExpectError: regexp.MustCompile(`modification\s+time\s+1975-01-01T00:00:00Z\s+is\s+before\s+1980-01-01T00:00:00Z`),
+			},
+		},
+	})
+}
+
+func TestDataSource_IncludeDirectories(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "tar_file_acc_test_include_directories.tar.gz")
+	dir := createTestDirectoriesDir(t)
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+data "archive_file" "foo" {
+  type                = "tar.gz"
+  source_dir          = "%s"
+  output_path         = "%s"
+  excludes            = ["build/**"]
+  include_directories = true
+  directory_mode      = "0750"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("data.archive_file.foo", "output_path", func(value string) error {
+					headers := tarHeaders(t, value)
+					if len(headers) != 7 {
+						return fmt.Errorf("expected 7 entries, got %d", len(headers))
+					}
+
+					for _, name := range []string{"app/", "lib/", "logs/", "tmp/", "tmp/cache/"} {
+						header, ok := headers[name]
+						if !ok {
+							return fmt.Errorf("expected directory %s", name)
+						}
+						if mode := header.FileInfo().Mode(); mode != os.ModeDir|0750 {
+							return fmt.Errorf("expected directory %s with mode 0750, got %s", name, mode)
+						}
+					}
+					return nil
+				}),
+			},
+		},
+	})
+}
+
 func testAccArchiveFileSize(filename string, fileSize *string) r.TestCheckFunc {
 	return func(s *terraform.State) error {
 		*fileSize = ""
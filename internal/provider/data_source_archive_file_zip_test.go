@@ -127,7 +127,7 @@ func TestAccZipArchiveFile_SourceConfigMissing(t *testing.T) {
 		Steps: []r.TestStep{
 			{
 				Config:      testAccArchiveSourceConfigMissing("zip"),
-				ExpectError: regexp.MustCompile(`.*At least one of these attributes must be configured:\n\[source,source_content_filename,source_file,source_dir]`),
+				ExpectError: regexp.MustCompile(`.*At least one of these attributes must be configured:\n\[source,source_content_filename,source_file,source_dir,python_wheels,source_directory,source_git,source_archive,source_url,entry]`),
 			},
 		},
 	})
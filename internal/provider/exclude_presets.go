@@ -0,0 +1,103 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ExcludePresetVCS       = "vcs"
+	ExcludePresetPython    = "python"
+	ExcludePresetNode      = "node"
+	ExcludePresetTerraform = "terraform"
+	ExcludePresetOSJunk    = "os_junk"
+)
+
+// excludePresetNames are the names of the exclude presets, in the order they are documented.
+var excludePresetNames = []string{
+	ExcludePresetVCS,
+	ExcludePresetPython,
+	ExcludePresetNode,
+	ExcludePresetTerraform,
+	ExcludePresetOSJunk,
+}
+
+// excludePresets are the patterns of each exclude preset. They match at any level of the directory, and are written
+// so that they have the same meaning with both the glob and the gitignore syntax of excludes.
+var excludePresets = map[string][]string{
+	ExcludePresetVCS: {
+		"**/.git",
+		"**/.gitattributes",
+		"**/.gitignore",
+		"**/.gitmodules",
+		"**/.hg",
+		"**/.hgignore",
+		"**/.svn",
+		"**/.bzr",
+	},
+	ExcludePresetPython: {
+		"**/__pycache__",
+		"**/*.pyc",
+		"**/*.pyo",
+		"**/*.egg-info",
+		"**/.pytest_cache",
+		"**/.mypy_cache",
+		"**/.ruff_cache",
+		"**/.tox",
+		"**/.venv",
+	},
+	ExcludePresetNode: {
+		"**/node_modules/.cache",
+		"**/.npm",
+		"**/.yarn/cache",
+		"**/.eslintcache",
+		"**/npm-debug.log*",
+		"**/yarn-debug.log*",
+		"**/yarn-error.log*",
+	},
+	ExcludePresetTerraform: {
+		"**/.terraform",
+		"**/.terraform.lock.hcl",
+		"**/*.tfstate",
+		"**/*.tfstate.*",
+		"**/*.tfplan",
+		"**/crash.log",
+		"**/crash.*.log",
+	},
+	ExcludePresetOSJunk: {
+		"**/.DS_Store",
+		"**/._*",
+		"**/.AppleDouble",
+		"**/.Spotlight-V100",
+		"**/.Trashes",
+		"**/Thumbs.db",
+		"**/ehthumbs.db",
+		"**/desktop.ini",
+		"**/$RECYCLE.BIN",
+	},
+}
+
+// presetExcludes returns the patterns of presets followed by excludes. Preset patterns come first so that, with the
+// gitignore syntax, excludes can re-include files excluded by a preset.
+func presetExcludes(presets []string, excludes []string) []string {
+	var patterns []string
+	for _, preset := range presets {
+		patterns = append(patterns, excludePresets[preset]...)
+	}
+
+	return append(patterns, excludes...)
+}
+
+// excludePresetsDescription documents the patterns of each preset, so that the documentation generated from the
+// schema cannot drift from excludePresets.
+func excludePresetsDescription() string {
+	presets := make([]string, len(excludePresetNames))
+	for i, name := range excludePresetNames {
+		presets[i] = fmt.Sprintf("`%s` excludes `%s`", name, strings.Join(excludePresets[name], "`, `"))
+	}
+
+	return strings.Join(presets, "; ")
+}
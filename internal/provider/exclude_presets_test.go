@@ -0,0 +1,113 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestExcludePresets(t *testing.T) {
+	testCases := map[string]struct {
+		excluded []string
+		kept     []string
+	}{
+		ExcludePresetVCS: {
+			excluded: []string{".git/HEAD", ".gitignore", "lib/.gitattributes", "vendor/dep/.hg/store", ".svn/entries"},
+			kept:     []string{"main.py", "git.txt", "docs/.github/CODEOWNERS"},
+		},
+		ExcludePresetPython: {
+			excluded: []string{
+				"__pycache__/main.cpython-312.pyc", "pkg/util.pyc", "pkg/__pycache__/util.cpython-312.pyc",
+				"lib.egg-info/PKG-INFO", ".pytest_cache/README.md", ".venv/bin/python", "pkg/.mypy_cache/data",
+			},
+			kept: []string{"main.py", "pkg/util.py", "requirements.txt"},
+		},
+		ExcludePresetNode: {
+			excluded: []string{"node_modules/.cache/babel/x.json", ".npm/_logs/log", "npm-debug.log", "yarn-error.log.1"},
+			kept:     []string{"index.js", "node_modules/lodash/index.js", "package.json"},
+		},
+		ExcludePresetTerraform: {
+			excluded: []string{
+				".terraform/providers/p", "infra/.terraform.lock.hcl", "terraform.tfstate",
+				"terraform.tfstate.backup", "plan.tfplan", "crash.log",
+			},
+			kept: []string{"main.tf", "terraform.tfvars", "handler.py"},
+		},
+		ExcludePresetOSJunk: {
+			excluded: []string{".DS_Store", "assets/.DS_Store", "._main.py", "images/Thumbs.db", "desktop.ini"},
+			kept:     []string{"main.py", "assets/logo.png"},
+		},
+	}
+
+	for preset, tc := range testCases {
+		for _, syntax := range []string{ExcludesSyntaxGlob, ExcludesSyntaxGitignore} {
+			t.Run(preset+"/"+syntax, func(t *testing.T) {
+				dir := t.TempDir()
+
+				files := make(map[string]string)
+				for _, name := range append(tc.excluded, tc.kept...) {
+					files[name] = name
+				}
+				writeTestFiles(t, dir, files)
+
+				entries, err := walkDir(dir, ArchiveDirOpts{
+					Excludes:       presetExcludes([]string{preset}, nil),
+					ExcludesSyntax: syntax,
+				})
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				want := append([]string(nil), tc.kept...)
+				sort.Strings(want)
+
+				if got := entryNames(entries); !reflect.DeepEqual(got, want) {
+					t.Errorf("got files %q, want %q", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestExcludePresetNames(t *testing.T) {
+	// The names validate and document the presets, so they must list every preset.
+	names := make([]string, 0, len(excludePresets))
+	for name := range excludePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	want := append([]string(nil), excludePresetNames...)
+	sort.Strings(want)
+
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got presets %q, want %q", names, want)
+	}
+}
+
+func TestPresetExcludes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"main.py":         "main",
+		"main.pyc":        "compiled",
+		"vendor/lib.pyc":  "compiled",
+		".DS_Store":       "junk",
+		"tests/test_a.py": "test",
+	})
+
+	// Excludes follow the preset patterns, so they can re-include files excluded by a preset.
+	entries, err := walkDir(dir, ArchiveDirOpts{
+		Excludes:       presetExcludes([]string{ExcludePresetPython, ExcludePresetOSJunk}, []string{"tests/", "!vendor/*.pyc"}),
+		ExcludesSyntax: ExcludesSyntaxGitignore,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got, want := entryNames(entries), []string{"main.py", "vendor/lib.pyc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got files %q, want %q", got, want)
+	}
+}
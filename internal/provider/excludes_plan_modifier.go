@@ -0,0 +1,62 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"context"
+	"slices"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// excludesOrderModifier plans the excludes of the prior state when the patterns only changed order and
+// excludes_syntax, read from the same object, is not gitignore, as the order only matters to gitignore patterns. This
+// keeps the archives of configurations written when excludes was a set from being replaced.
+type excludesOrderModifier struct{}
+
+func (m excludesOrderModifier) Description(_ context.Context) string {
+	return "Ignores changes to the order of excludes unless excludes_syntax is gitignore."
+}
+
+func (m excludesOrderModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m excludesOrderModifier) PlanModifyList(ctx context.Context, req planmodifier.ListRequest, resp *planmodifier.ListResponse) {
+	if req.StateValue.IsNull() || req.PlanValue.IsNull() || req.PlanValue.IsUnknown() || req.PlanValue.Equal(req.StateValue) {
+		return
+	}
+
+	var syntax types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, req.Path.ParentPath().AtName("excludes_syntax"), &syntax)...)
+	if resp.Diagnostics.HasError() || syntax.ValueString() == ExcludesSyntaxGitignore {
+		return
+	}
+
+	var state, plan []types.String
+	resp.Diagnostics.Append(req.StateValue.ElementsAs(ctx, &state, false)...)
+	resp.Diagnostics.Append(req.PlanValue.ElementsAs(ctx, &plan, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if slices.ContainsFunc(plan, types.String.IsUnknown) {
+		return
+	}
+
+	if slices.Equal(sortedPatterns(state), sortedPatterns(plan)) {
+		resp.PlanValue = req.StateValue
+	}
+}
+
+func sortedPatterns(patterns []types.String) []string {
+	values := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		values[i] = pattern.ValueString()
+	}
+	slices.Sort(values)
+
+	return values
+}
@@ -0,0 +1,155 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// FileModeRule sets the mode of the files whose path inside the archive matches Pattern.
+type FileModeRule struct {
+	Pattern string
+	Mode    os.FileMode
+}
+
+type FileModeOpts struct {
+	// Rules are evaluated in order, and the first rule matching a file sets its mode.
+	Rules []FileModeRule
+	// DirectoryMode is the mode of directory entries when set.
+	DirectoryMode os.FileMode
+	// AutoExecutable gives files not matched by any rule 0755 when they are executables or scripts, and 0644 otherwise.
+	AutoExecutable bool
+}
+
+// executableMagics are the headers of scripts and of ELF and Mach-O binaries.
+var executableMagics = [][]byte{
+	[]byte("#!"),
+	[]byte("\x7fELF"),
+	{0xfe, 0xed, 0xfa, 0xce},
+	{0xfe, 0xed, 0xfa, 0xcf},
+	{0xce, 0xfa, 0xed, 0xfe},
+	{0xcf, 0xfa, 0xed, 0xfe},
+}
+
+// fatMachOMagic starts universal Mach-O binaries, but also Java class files. Universal binaries are told apart by
+// the number of architectures following it, which is small, while class files have their version there, which is at
+// least 45 for the oldest Java release. The file command draws the line at 20 architectures as well.
+var fatMachOMagic = []byte{0xca, 0xfe, 0xba, 0xbe}
+
+const maxFatMachOArchs = 20
+
+// applyFileModes sets the mode of entries according to opts. Entries which already have a mode, such as those of
+// entry blocks with a mode, and symbolic links are left unchanged.
+func applyFileModes(entries []ArchiveEntry, opts FileModeOpts) error {
+	for _, rule := range opts.Rules {
+		if !doublestar.ValidatePattern(rule.Pattern) {
+			return fmt.Errorf("invalid file mode rule pattern: %s", rule.Pattern)
+		}
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		if entry.ModeSet || entry.LinkTarget != "" {
+			continue
+		}
+
+		if entry.SourceInfo != nil && entry.SourceInfo.IsDir() {
+			if opts.DirectoryMode != 0 {
+				entry.setMode(os.ModeDir | opts.DirectoryMode)
+			}
+			continue
+		}
+
+		if mode, ok := ruleFileMode(entry.Name, opts.Rules); ok {
+			entry.setMode(mode)
+			continue
+		}
+
+		if opts.AutoExecutable {
+			executable, err := isExecutable(*entry)
+			if err != nil {
+				return err
+			}
+
+			mode := os.FileMode(0644)
+			if executable {
+				mode = 0755
+			}
+			entry.setMode(mode)
+		}
+	}
+
+	return nil
+}
+
+func ruleFileMode(name string, rules []FileModeRule) (os.FileMode, bool) {
+	for _, rule := range rules {
+		if isMatch, _ := doublestar.Match(rule.Pattern, name); isMatch {
+			return rule.Mode, true
+		}
+	}
+
+	return 0, false
+}
+
+// isExecutable reports whether the content of entry starts with a shebang or the header of an ELF, Mach-O or universal
+// Mach-O binary.
+func isExecutable(entry ArchiveEntry) (bool, error) {
+	header := entry.Content
+	if entry.SourcePath != "" {
+		f, err := os.Open(entry.SourcePath)
+		if err != nil {
+			return false, fmt.Errorf("error reading file for archival: %s", err)
+		}
+		defer f.Close()
+
+		header = make([]byte, 8)
+		n, err := io.ReadFull(f, header)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			return false, fmt.Errorf("error reading file for archival: %s", err)
+		}
+		header = header[:n]
+	}
+
+	for _, magic := range executableMagics {
+		if bytes.HasPrefix(header, magic) {
+			return true, nil
+		}
+	}
+
+	if bytes.HasPrefix(header, fatMachOMagic) && len(header) >= 8 {
+		archs := binary.BigEndian.Uint32(header[4:8])
+		return archs > 0 && archs < maxFatMachOArchs, nil
+	}
+
+	return false, nil
+}
+
+// dirFileMode returns the permissions of a directory entry, which are normalized to 0755 unless the entry has a mode,
+// such as one set by directory_mode.
+func dirFileMode(entry ArchiveEntry) os.FileMode {
+	if entry.ModeSet {
+		return entry.Mode.Perm()
+	}
+
+	return 0755
+}
+
+// parseFileMode parses an octal file mode such as "0644".
+func parseFileMode(mode string) (os.FileMode, error) {
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mode %q: must be an octal file mode such as \"0644\"", mode)
+	}
+
+	return os.FileMode(m), nil
+}
@@ -0,0 +1,144 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyFileModes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"bootstrap": "\x7fELF\x02\x01\x01",
+		"hook.sh":   "#!/bin/sh\necho hook\n",
+		"README.md": "readme",
+		"empty.txt": "",
+	})
+
+	var entries []ArchiveEntry
+	for _, name := range []string{"bootstrap", "hook.sh", "README.md", "empty.txt"} {
+		entry, err := fileEntry(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+
+	entries = append(entries,
+		ArchiveEntry{Name: "bin/app", Content: []byte{0xcf, 0xfa, 0xed, 0xfe, 0x07}},
+		ArchiveEntry{Name: "bin/run.py", Content: []byte("print('run')")},
+		ArchiveEntry{Name: "bin/secret.sh", Content: []byte("#!/bin/sh\n")},
+		ArchiveEntry{Name: "config/app.json", Content: []byte("{}"), Mode: 0600, ModeSet: true},
+		ArchiveEntry{Name: "latest", LinkTarget: "bin/app"},
+		ArchiveEntry{Name: "lib", SourceInfo: testFileInfo{mode: os.ModeDir | 0700}},
+	)
+
+	err := applyFileModes(entries, FileModeOpts{
+		Rules: []FileModeRule{
+			{Pattern: "bin/secret.sh", Mode: 0700},
+			{Pattern: "bin/**", Mode: 0750},
+		},
+		DirectoryMode:  0755,
+		AutoExecutable: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := map[string]os.FileMode{
+		"bootstrap":       0755,
+		"hook.sh":         0755,
+		"README.md":       0644,
+		"empty.txt":       0644,
+		"bin/app":         0750,
+		"bin/run.py":      0750,
+		"bin/secret.sh":   0700,
+		"config/app.json": 0600,
+		"latest":          0,
+		"lib":             os.ModeDir | 0755,
+	}
+	for _, entry := range entries {
+		if entry.Mode != want[entry.Name] {
+			t.Errorf("%s: got mode %s, want %s", entry.Name, entry.Mode, want[entry.Name])
+		}
+	}
+}
+
+func TestApplyFileModes_Defaults(t *testing.T) {
+	entries := []ArchiveEntry{
+		{Name: "bootstrap", Content: []byte("\x7fELF")},
+		{Name: "lib", SourceInfo: testFileInfo{mode: os.ModeDir | 0700}},
+	}
+
+	if err := applyFileModes(entries, FileModeOpts{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Without options, modes are left to the archiver.
+	for _, entry := range entries {
+		if entry.ModeSet {
+			t.Errorf("%s: got mode %s, want none", entry.Name, entry.Mode)
+		}
+	}
+
+	if err := applyFileModes(entries, FileModeOpts{Rules: []FileModeRule{{Pattern: "bin/[", Mode: 0755}}}); err == nil {
+		t.Errorf("expected error for invalid pattern")
+	}
+}
+
+func TestIsExecutable(t *testing.T) {
+	testCases := map[string]bool{
+		"#!/usr/bin/env python3\n":         true,
+		"\x7fELF\x02":                      true,
+		"\xfe\xed\xfa\xce":                 true,
+		"\xcf\xfa\xed\xfe":                 true,
+		"\xca\xfe\xba\xbe\x00\x00\x00\x02": true,
+		"\xca\xfe\xba\xbe\x00\x00\x00\x34": false,
+		"\xca\xfe\xba\xbe":                 false,
+		"#":                                false,
+		"":                                 false,
+		"PK\x03\x04":                       false,
+		"# comment\n":                      false,
+	}
+
+	for content, want := range testCases {
+		got, err := isExecutable(ArchiveEntry{Content: []byte(content)})
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", content, err)
+		}
+		if got != want {
+			t.Errorf("isExecutable(%q) = %t, want %t", content, got, want)
+		}
+	}
+}
+
+func TestIsExecutable_SourcePath(t *testing.T) {
+	dir := t.TempDir()
+
+	testCases := map[string]struct {
+		content []byte
+		want    bool
+	}{
+		// Java 8 class files start with the magic of universal Mach-O binaries followed by their version.
+		"Main.class": {content: []byte{0xca, 0xfe, 0xba, 0xbe, 0x00, 0x00, 0x00, 0x34, 0x00, 0x1d}, want: false},
+		"universal":  {content: []byte{0xca, 0xfe, 0xba, 0xbe, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00}, want: true},
+	}
+
+	for name, tc := range testCases {
+		sourcePath := filepath.Join(dir, name)
+		if err := os.WriteFile(sourcePath, tc.content, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := isExecutable(ArchiveEntry{Name: name, SourcePath: sourcePath})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+		if got != tc.want {
+			t.Errorf("isExecutable(%s) = %t, want %t", name, got, tc.want)
+		}
+	}
+}
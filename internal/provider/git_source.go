@@ -0,0 +1,797 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"container/list"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type GitSourceOpts struct {
+	RepositoryPath string
+	Ref            string
+	Subdirectory   string
+}
+
+// gitEntries returns the files committed at opts.Ref in the repository, like `git archive` does, together with the
+// SHA of the commit they were read from. Files are archived relative to opts.Subdirectory with their recorded modes.
+func gitEntries(opts GitSourceOpts) (string, []ArchiveEntry, error) {
+	repo, err := openGitRepository(opts.RepositoryPath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer repo.close()
+
+	commit, err := repo.resolve(opts.Ref)
+	if err != nil {
+		return "", nil, err
+	}
+
+	tree, err := repo.commitTree(commit)
+	if err != nil {
+		return "", nil, err
+	}
+
+	subdirectory := strings.Trim(path.Clean("/"+filepath.ToSlash(opts.Subdirectory)), "/")
+	if subdirectory != "" {
+		tree, err = repo.subtree(tree, subdirectory)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not read subdirectory %s at commit %s: %w", subdirectory, commit, err)
+		}
+	}
+
+	var entries []ArchiveEntry
+	if err := repo.treeEntries(tree, "", &entries); err != nil {
+		return "", nil, err
+	}
+
+	return commit, entries, nil
+}
+
+// gitRepository reads objects and refs from the object database of a local git repository.
+type gitRepository struct {
+	// gitDir holds the refs of the worktree, such as HEAD, and commonDir the objects and the shared refs.
+	gitDir    string
+	commonDir string
+	packs     []*gitPack
+}
+
+func openGitRepository(repositoryPath string) (*gitRepository, error) {
+	gitDir, err := findGitDir(repositoryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &gitRepository{
+		gitDir:    gitDir,
+		commonDir: gitDir,
+	}
+
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+		repo.commonDir = commonDir
+	}
+
+	if data, err := os.ReadFile(filepath.Join(repo.commonDir, "config")); err == nil {
+		if bytes.Contains(data, []byte("objectformat = sha256")) {
+			return nil, fmt.Errorf("unsupported git repository %s: SHA-256 object format", repositoryPath)
+		}
+	}
+
+	indexes, err := filepath.Glob(filepath.Join(repo.commonDir, "objects", "pack", "*.idx"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(indexes)
+
+	for _, index := range indexes {
+		pack, err := openGitPack(index)
+		if err != nil {
+			repo.close()
+			return nil, err
+		}
+		repo.packs = append(repo.packs, pack)
+	}
+
+	return repo, nil
+}
+
+// findGitDir returns the git directory of the worktree or bare repository at repositoryPath.
+func findGitDir(repositoryPath string) (string, error) {
+	dotGit := filepath.Join(repositoryPath, ".git")
+
+	fi, err := os.Stat(dotGit)
+	switch {
+	case err == nil && fi.IsDir():
+		return dotGit, nil
+	case err == nil:
+		// Linked worktrees and submodules use a .git file pointing to the git directory.
+		data, err := os.ReadFile(dotGit)
+		if err != nil {
+			return "", err
+		}
+
+		gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+		if !ok {
+			return "", fmt.Errorf("invalid .git file in %s", repositoryPath)
+		}
+		if !filepath.IsAbs(gitDir) {
+			gitDir = filepath.Join(repositoryPath, gitDir)
+		}
+		return gitDir, nil
+	case !os.IsNotExist(err):
+		return "", err
+	}
+
+	// A bare repository.
+	if _, err := os.Stat(filepath.Join(repositoryPath, "objects")); err == nil {
+		if _, err := os.Stat(filepath.Join(repositoryPath, "HEAD")); err == nil {
+			return repositoryPath, nil
+		}
+	}
+
+	return "", fmt.Errorf("not a git repository: %s", repositoryPath)
+}
+
+func (r *gitRepository) close() {
+	for _, pack := range r.packs {
+		pack.file.Close()
+	}
+	r.packs = nil
+}
+
+// resolve returns the SHA of the commit ref points to, following symbolic refs and annotated tags. The ref is looked
+// up the same way `git rev-parse` does, and defaults to HEAD.
+func (r *gitRepository) resolve(ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	sha := ""
+	if isGitSHA(ref) {
+		sha = strings.ToLower(ref)
+	} else {
+		if strings.Contains(ref, "..") || strings.HasPrefix(ref, "/") {
+			return "", fmt.Errorf("invalid git ref: %s", ref)
+		}
+
+		for _, name := range []string{ref, "refs/" + ref, "refs/tags/" + ref, "refs/heads/" + ref, "refs/remotes/" + ref, "refs/remotes/" + ref + "/HEAD"} {
+			var err error
+			sha, err = r.readRef(name, 0)
+			if err != nil {
+				return "", err
+			}
+			if sha != "" {
+				break
+			}
+		}
+
+		if sha == "" {
+			return "", fmt.Errorf("could not resolve git ref: %s", ref)
+		}
+	}
+
+	// Peel annotated tags down to the commit they point to.
+	for {
+		objType, data, err := r.readObject(sha)
+		if err != nil {
+			return "", err
+		}
+
+		switch objType {
+		case "commit":
+			return sha, nil
+		case "tag":
+			target, ok := gitHeader(data, "object")
+			if !ok {
+				return "", fmt.Errorf("invalid git tag object %s", sha)
+			}
+			sha = target
+		default:
+			return "", fmt.Errorf("git ref %s points to a %s, not a commit", ref, objType)
+		}
+	}
+}
+
+// readRef returns the SHA of the ref name, or an empty string if there is no such ref.
+func (r *gitRepository) readRef(name string, depth int) (string, error) {
+	if depth > 5 {
+		return "", fmt.Errorf("too many levels of symbolic git refs: %s", name)
+	}
+
+	dir := r.commonDir
+	if !strings.HasPrefix(name, "refs/") {
+		dir = r.gitDir
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err == nil {
+		value := strings.TrimSpace(string(data))
+		if target, ok := strings.CutPrefix(value, "ref: "); ok {
+			return r.readRef(target, depth+1)
+		}
+		if isGitSHA(value) {
+			return strings.ToLower(value), nil
+		}
+	}
+
+	// Refs which are not stored as files can be found in packed-refs.
+	packed, err := os.ReadFile(filepath.Join(r.commonDir, "packed-refs"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(packed), "\n") {
+		sha, refName, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if ok && refName == name && isGitSHA(sha) {
+			return strings.ToLower(sha), nil
+		}
+	}
+
+	return "", nil
+}
+
+// commitTree returns the SHA of the tree of a commit.
+func (r *gitRepository) commitTree(commit string) (string, error) {
+	objType, data, err := r.readObject(commit)
+	if err != nil {
+		return "", err
+	}
+
+	tree, ok := gitHeader(data, "tree")
+	if objType != "commit" || !ok {
+		return "", fmt.Errorf("invalid git commit object %s", commit)
+	}
+
+	return tree, nil
+}
+
+// subtree returns the SHA of the tree at the slash separated path dir below tree.
+func (r *gitRepository) subtree(tree, dir string) (string, error) {
+	for _, name := range strings.Split(dir, "/") {
+		entries, err := r.readTree(tree)
+		if err != nil {
+			return "", err
+		}
+
+		found := false
+		for _, entry := range entries {
+			if entry.name == name && entry.mode == "40000" {
+				tree = entry.sha
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return "", errors.New("no such directory")
+		}
+	}
+
+	return tree, nil
+}
+
+// treeEntries appends the files of tree, recursively, with their names prefixed by prefix.
+func (r *gitRepository) treeEntries(tree, prefix string, entries *[]ArchiveEntry) error {
+	treeEntries, err := r.readTree(tree)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range treeEntries {
+		name := path.Join(prefix, entry.name)
+
+		var mode os.FileMode
+		switch entry.mode {
+		case "40000":
+			if err := r.treeEntries(entry.sha, name, entries); err != nil {
+				return err
+			}
+			continue
+		case "160000":
+			// Submodules are not part of the object database of the repository, as with `git archive`.
+			continue
+		case "100755":
+			mode = 0755
+		case "120000":
+			mode = os.ModeSymlink | 0777
+		default:
+			mode = 0644
+		}
+
+		objType, data, err := r.readObject(entry.sha)
+		if err != nil {
+			return err
+		}
+		if objType != "blob" {
+			return fmt.Errorf("invalid git tree entry %s: %s is a %s", name, entry.sha, objType)
+		}
+
+		archiveEntry := ArchiveEntry{
+			Name:       name,
+			Content:    data,
+			SourceInfo: gitFileInfo{name: entry.name, size: int64(len(data)), mode: mode},
+		}
+
+		if mode&os.ModeSymlink != 0 {
+			if err := checkLinkTarget(name, string(data)); err != nil {
+				return err
+			}
+
+			archiveEntry.Content = nil
+			archiveEntry.LinkTarget = string(data)
+		}
+
+		*entries = append(*entries, archiveEntry)
+	}
+
+	return nil
+}
+
+type gitTreeEntry struct {
+	mode string
+	name string
+	sha  string
+}
+
+func (r *gitRepository) readTree(tree string) ([]gitTreeEntry, error) {
+	objType, data, err := r.readObject(tree)
+	if err != nil {
+		return nil, err
+	}
+	if objType != "tree" {
+		return nil, fmt.Errorf("invalid git tree object %s", tree)
+	}
+
+	var entries []gitTreeEntry
+	for len(data) > 0 {
+		space := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if space < 0 || nul < space || len(data) < nul+21 {
+			return nil, fmt.Errorf("invalid git tree object %s", tree)
+		}
+
+		entries = append(entries, gitTreeEntry{
+			mode: string(data[:space]),
+			name: string(data[space+1 : nul]),
+			sha:  hex.EncodeToString(data[nul+1 : nul+21]),
+		})
+		data = data[nul+21:]
+	}
+
+	return entries, nil
+}
+
+// readObject returns the type and content of an object, read from its loose object file or from a packfile.
+func (r *gitRepository) readObject(sha string) (string, []byte, error) {
+	f, err := os.Open(filepath.Join(r.commonDir, "objects", sha[:2], sha[2:]))
+	if err == nil {
+		defer f.Close()
+		return readLooseGitObject(f, sha)
+	}
+	if !os.IsNotExist(err) {
+		return "", nil, err
+	}
+
+	id, err := hex.DecodeString(sha)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid git object name: %s", sha)
+	}
+
+	for _, pack := range r.packs {
+		if offset, ok := pack.find(id); ok {
+			objType, data, err := pack.readObject(r, offset)
+			if err != nil {
+				return "", nil, fmt.Errorf("error reading git object %s from %s: %w", sha, pack.file.Name(), err)
+			}
+			return gitObjectTypes[objType], data, nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("git object not found: %s", sha)
+}
+
+func readLooseGitObject(f io.Reader, sha string) (string, []byte, error) {
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return "", nil, fmt.Errorf("error reading git object %s: %w", sha, err)
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return "", nil, fmt.Errorf("error reading git object %s: %w", sha, err)
+	}
+
+	header, content, ok := bytes.Cut(data, []byte{0})
+	if !ok {
+		return "", nil, fmt.Errorf("invalid git object %s", sha)
+	}
+
+	objType, size, _ := strings.Cut(string(header), " ")
+	if size != strconv.Itoa(len(content)) {
+		return "", nil, fmt.Errorf("invalid git object %s: size mismatch", sha)
+	}
+
+	return objType, content, nil
+}
+
+const (
+	gitObjectOfsDelta = 6
+	gitObjectRefDelta = 7
+)
+
+var gitObjectTypes = map[int]string{
+	1: "commit",
+	2: "tree",
+	3: "blob",
+	4: "tag",
+}
+
+// gitDeltaBaseCacheLimit bounds the size of the objects kept by the delta base cache of a packfile, like the default
+// of git's core.deltaBaseCacheLimit.
+const gitDeltaBaseCacheLimit = 96 << 20
+
+// gitPack is a packfile together with its version 2 index.
+type gitPack struct {
+	file         *os.File
+	fanout       [256]uint32
+	names        []byte
+	offsets      []byte
+	largeOffsets []byte
+	// bases caches the delta base objects read from the packfile, so that the objects of a delta chain don't each
+	// inflate the whole chain again.
+	bases *gitDeltaBaseCache
+}
+
+func openGitPack(indexPath string) (*gitPack, error) {
+	index, err := os.ReadFile(indexPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(index) < 8+256*4 || !bytes.Equal(index[:8], []byte{0xff, 't', 'O', 'c', 0, 0, 0, 2}) {
+		return nil, fmt.Errorf("unsupported git pack index: %s", indexPath)
+	}
+
+	p := &gitPack{bases: newGitDeltaBaseCache(gitDeltaBaseCacheLimit)}
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(index[8+i*4:])
+	}
+
+	count := int(p.fanout[255])
+	namesStart := 8 + 256*4
+	offsetsStart := namesStart + count*20 + count*4
+	largeOffsetsStart := offsetsStart + count*4
+	if len(index) < largeOffsetsStart {
+		return nil, fmt.Errorf("invalid git pack index: %s", indexPath)
+	}
+
+	p.names = index[namesStart : namesStart+count*20]
+	p.offsets = index[offsetsStart:largeOffsetsStart]
+	p.largeOffsets = index[largeOffsetsStart:]
+
+	p.file, err = os.Open(strings.TrimSuffix(indexPath, ".idx") + ".pack")
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// find returns the offset of the object id in the packfile.
+func (p *gitPack) find(id []byte) (int64, bool) {
+	lo := 0
+	if id[0] > 0 {
+		lo = int(p.fanout[id[0]-1])
+	}
+	hi := int(p.fanout[id[0]])
+
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(p.names[(lo+i)*20:(lo+i+1)*20], id) >= 0
+	})
+	if i >= hi || !bytes.Equal(p.names[i*20:(i+1)*20], id) {
+		return 0, false
+	}
+
+	offset := binary.BigEndian.Uint32(p.offsets[i*4:])
+	if offset&0x80000000 == 0 {
+		return int64(offset), true
+	}
+
+	large := int(offset&0x7fffffff) * 8
+	if large+8 > len(p.largeOffsets) {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(p.largeOffsets[large:])), true
+}
+
+// readObject returns the type and content of the object at offset, resolving deltas against their base object.
+func (p *gitPack) readObject(r *gitRepository, offset int64) (int, []byte, error) {
+	br := bufio.NewReader(io.NewSectionReader(p.file, offset, math.MaxInt64-offset))
+
+	c, err := br.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	objType := int(c>>4) & 7
+	size := uint64(c & 0x0f)
+	for shift := 4; c&0x80 != 0; shift += 7 {
+		if c, err = br.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+		size |= uint64(c&0x7f) << shift
+	}
+
+	var baseType int
+	var base []byte
+
+	switch objType {
+	case gitObjectOfsDelta:
+		if c, err = br.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+		distance := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = br.ReadByte(); err != nil {
+				return 0, nil, err
+			}
+			distance = (distance+1)<<7 | int64(c&0x7f)
+		}
+		if distance <= 0 || distance > offset {
+			return 0, nil, errors.New("invalid delta base offset")
+		}
+
+		baseType, base, err = p.readDeltaBase(r, offset-distance)
+		if err != nil {
+			return 0, nil, err
+		}
+	case gitObjectRefDelta:
+		id := make([]byte, 20)
+		if _, err := io.ReadFull(br, id); err != nil {
+			return 0, nil, err
+		}
+
+		if baseOffset, ok := p.find(id); ok {
+			baseType, base, err = p.readDeltaBase(r, baseOffset)
+			if err != nil {
+				return 0, nil, err
+			}
+			break
+		}
+
+		var baseTypeName string
+		baseTypeName, base, err = r.readObject(hex.EncodeToString(id))
+		if err != nil {
+			return 0, nil, err
+		}
+		for t, name := range gitObjectTypes {
+			if name == baseTypeName {
+				baseType = t
+			}
+		}
+	default:
+		if _, ok := gitObjectTypes[objType]; !ok {
+			return 0, nil, fmt.Errorf("invalid object type %d", objType)
+		}
+	}
+
+	zr, err := zlib.NewReader(br)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer zr.Close()
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return 0, nil, err
+	}
+
+	if base == nil {
+		return objType, data, nil
+	}
+
+	data, err = applyGitDelta(base, data)
+	return baseType, data, err
+}
+
+// readDeltaBase returns the type and content of the delta base object at offset, from the delta base cache when
+// possible.
+func (p *gitPack) readDeltaBase(r *gitRepository, offset int64) (int, []byte, error) {
+	if objType, data, ok := p.bases.get(offset); ok {
+		return objType, data, nil
+	}
+
+	objType, data, err := p.readObject(r, offset)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	p.bases.add(offset, objType, data)
+	return objType, data, nil
+}
+
+// gitDeltaBaseCache keeps the most recently used delta base objects of a packfile by offset, evicting the least
+// recently used ones once their size exceeds limit.
+type gitDeltaBaseCache struct {
+	limit   int
+	size    int
+	objects map[int64]*list.Element
+	lru     *list.List // of *gitCachedObject, most recently used first
+}
+
+type gitCachedObject struct {
+	offset  int64
+	objType int
+	data    []byte
+}
+
+func newGitDeltaBaseCache(limit int) *gitDeltaBaseCache {
+	return &gitDeltaBaseCache{
+		limit:   limit,
+		objects: make(map[int64]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (c *gitDeltaBaseCache) get(offset int64) (int, []byte, bool) {
+	e, ok := c.objects[offset]
+	if !ok {
+		return 0, nil, false
+	}
+
+	c.lru.MoveToFront(e)
+	obj := e.Value.(*gitCachedObject) //nolint:forcetypeassert // The list only holds *gitCachedObject.
+	return obj.objType, obj.data, true
+}
+
+func (c *gitDeltaBaseCache) add(offset int64, objType int, data []byte) {
+	if _, ok := c.objects[offset]; ok || len(data) > c.limit {
+		return
+	}
+
+	c.objects[offset] = c.lru.PushFront(&gitCachedObject{offset: offset, objType: objType, data: data})
+	c.size += len(data)
+
+	for c.size > c.limit {
+		e := c.lru.Back()
+		obj := c.lru.Remove(e).(*gitCachedObject) //nolint:forcetypeassert // The list only holds *gitCachedObject.
+		delete(c.objects, obj.offset)
+		c.size -= len(obj.data)
+	}
+}
+
+// applyGitDelta reconstructs an object from the content of its base object and a delta.
+func applyGitDelta(base, delta []byte) ([]byte, error) {
+	errInvalid := errors.New("invalid delta")
+
+	readSize := func() (int, bool) {
+		size := 0
+		for shift := 0; len(delta) > 0; shift += 7 {
+			c := delta[0]
+			delta = delta[1:]
+			size |= int(c&0x7f) << shift
+			if c&0x80 == 0 {
+				return size, true
+			}
+		}
+		return 0, false
+	}
+
+	baseSize, ok := readSize()
+	if !ok || baseSize != len(base) {
+		return nil, errInvalid
+	}
+	size, ok := readSize()
+	if !ok {
+		return nil, errInvalid
+	}
+
+	data := make([]byte, 0, size)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		switch {
+		case op&0x80 != 0:
+			// Copy a range of the base object, with the offset and size bytes present as flagged by op.
+			var offset, n int
+			for i := 0; i < 7; i++ {
+				if op&(1<<i) == 0 {
+					continue
+				}
+				if len(delta) == 0 {
+					return nil, errInvalid
+				}
+				if i < 4 {
+					offset |= int(delta[0]) << (8 * i)
+				} else {
+					n |= int(delta[0]) << (8 * (i - 4))
+				}
+				delta = delta[1:]
+			}
+			if n == 0 {
+				n = 0x10000
+			}
+			if offset+n > len(base) {
+				return nil, errInvalid
+			}
+			data = append(data, base[offset:offset+n]...)
+		case op != 0:
+			// Insert the next op bytes of the delta.
+			n := int(op)
+			if n > len(delta) {
+				return nil, errInvalid
+			}
+			data = append(data, delta[:n]...)
+			delta = delta[n:]
+		default:
+			return nil, errInvalid
+		}
+	}
+
+	if len(data) != size {
+		return nil, errInvalid
+	}
+
+	return data, nil
+}
+
+// gitHeader returns the value of the first header named key of a commit or tag object.
+func gitHeader(data []byte, key string) (string, bool) {
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, key+" "); ok {
+			return value, isGitSHA(value)
+		}
+	}
+
+	return "", false
+}
+
+func isGitSHA(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// gitFileInfo describes a file read from a git tree, with the mode recorded in the tree.
+type gitFileInfo struct {
+	name string
+	size int64
+	mode os.FileMode
+}
+
+func (fi gitFileInfo) Name() string       { return fi.name }
+func (fi gitFileInfo) Size() int64        { return fi.size }
+func (fi gitFileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi gitFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi gitFileInfo) IsDir() bool        { return false }
+func (fi gitFileInfo) Sys() any           { return nil }
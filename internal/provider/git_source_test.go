@@ -0,0 +1,274 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitEntries(t *testing.T) {
+	repo := createTestGitRepository(t)
+
+	commit, entries, err := gitEntries(GitSourceOpts{RepositoryPath: repo})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := runGit(t, repo, "rev-parse", "HEAD"); commit != want {
+		t.Errorf("got commit %s, want %s", commit, want)
+	}
+
+	ensureEntries(t, entries, map[string]string{
+		"README.md":       "readme v2",
+		"bin/run.sh":      "#!/bin/sh\necho run\n",
+		"link.txt":        "-> README.md",
+		"src/main.py":     testGitLargeFile("v2"),
+		"src/pkg/util.py": "util",
+	})
+
+	modes := map[string]os.FileMode{
+		"README.md":  0644,
+		"bin/run.sh": 0755,
+		"link.txt":   os.ModeSymlink | 0777,
+	}
+	for _, entry := range entries {
+		if want, ok := modes[entry.Name]; ok && entry.SourceInfo.Mode() != want {
+			t.Errorf("%s: got mode %s, want %s", entry.Name, entry.SourceInfo.Mode(), want)
+		}
+	}
+}
+
+func TestGitEntries_Ref(t *testing.T) {
+	repo := createTestGitRepository(t)
+
+	for _, ref := range []string{"v1", "refs/tags/v1", "v1-annotated", runGit(t, repo, "rev-parse", "v1")} {
+		commit, entries, err := gitEntries(GitSourceOpts{RepositoryPath: repo, Ref: ref})
+		if err != nil {
+			t.Fatalf("ref %s: unexpected error: %s", ref, err)
+		}
+
+		if want := runGit(t, repo, "rev-parse", "v1^{commit}"); commit != want {
+			t.Errorf("ref %s: got commit %s, want %s", ref, commit, want)
+		}
+
+		ensureEntries(t, entries, map[string]string{
+			"README.md":   "readme v1",
+			"src/main.py": testGitLargeFile("v1"),
+		})
+	}
+}
+
+func TestGitEntries_Subdirectory(t *testing.T) {
+	repo := createTestGitRepository(t)
+
+	_, entries, err := gitEntries(GitSourceOpts{RepositoryPath: repo, Ref: "main", Subdirectory: "src"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureEntries(t, entries, map[string]string{
+		"main.py":     testGitLargeFile("v2"),
+		"pkg/util.py": "util",
+	})
+
+	if _, _, err := gitEntries(GitSourceOpts{RepositoryPath: repo, Subdirectory: "missing"}); err == nil {
+		t.Errorf("expected error for missing subdirectory")
+	}
+}
+
+func TestGitEntries_Packed(t *testing.T) {
+	for name, args := range map[string][]string{
+		"ofs-delta": {"repack", "-a", "-d", "-f"},
+		"ref-delta": {"-c", "repack.useDeltaBaseOffset=false", "repack", "-a", "-d", "-f"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			repo := createTestGitRepository(t)
+			runGit(t, repo, args...)
+			runGit(t, repo, "pack-refs", "--all")
+			runGit(t, repo, "prune-packed")
+
+			objects, err := filepath.Glob(filepath.Join(repo, ".git", "objects", "??", "*"))
+			if err != nil || len(objects) > 0 {
+				t.Fatalf("expected all objects to be packed, found %d loose objects", len(objects))
+			}
+
+			_, entries, err := gitEntries(GitSourceOpts{RepositoryPath: repo, Ref: "v1-annotated"})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			ensureEntries(t, entries, map[string]string{
+				"README.md":   "readme v1",
+				"src/main.py": testGitLargeFile("v1"),
+			})
+
+			_, entries, err = gitEntries(GitSourceOpts{RepositoryPath: repo})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			ensureEntries(t, entries, map[string]string{
+				"README.md":       "readme v2",
+				"bin/run.sh":      "#!/bin/sh\necho run\n",
+				"link.txt":        "-> README.md",
+				"src/main.py":     testGitLargeFile("v2"),
+				"src/pkg/util.py": "util",
+			})
+		})
+	}
+}
+
+func TestGitEntries_SymlinkOutside(t *testing.T) {
+	linkedRepository := func(target string) string {
+		repo := createTestGitRepository(t)
+		if err := os.Symlink(target, filepath.Join(repo, "src", "link")); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, repo, "add", "-A")
+		runGit(t, repo, "commit", "--quiet", "-m", "link")
+
+		return repo
+	}
+
+	// The link points inside the archive only when the whole repository is archived.
+	if _, _, err := gitEntries(GitSourceOpts{RepositoryPath: linkedRepository("../README.md")}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	for target, subdirectory := range map[string]string{
+		"../README.md": "src",
+		"/etc/passwd":  "",
+	} {
+		_, _, err := gitEntries(GitSourceOpts{RepositoryPath: linkedRepository(target), Subdirectory: subdirectory})
+		if err == nil || !strings.Contains(err.Error(), "points outside of the archive") {
+			t.Errorf("%s: expected error for symbolic link outside of the archive, got: %v", target, err)
+		}
+	}
+}
+
+func TestGitEntries_InvalidRef(t *testing.T) {
+	repo := createTestGitRepository(t)
+
+	for _, ref := range []string{"missing", "../../HEAD"} {
+		if _, _, err := gitEntries(GitSourceOpts{RepositoryPath: repo, Ref: ref}); err == nil {
+			t.Errorf("ref %s: expected error", ref)
+		}
+	}
+
+	if _, _, err := gitEntries(GitSourceOpts{RepositoryPath: t.TempDir()}); err == nil {
+		t.Errorf("expected error for a directory which is not a git repository")
+	}
+}
+
+func TestApplyGitDelta(t *testing.T) {
+	base := []byte("hello world")
+	// Copy "hello " from the base, then insert "git".
+	delta := []byte{11, 9, 0x90, 6, 3, 'g', 'i', 't'}
+
+	got, err := applyGitDelta(base, delta)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(got) != "hello git" {
+		t.Errorf("got %q, want %q", got, "hello git")
+	}
+
+	if _, err := applyGitDelta(base, delta[:len(delta)-1]); err == nil {
+		t.Errorf("expected error for truncated delta")
+	}
+}
+
+func TestGitDeltaBaseCache(t *testing.T) {
+	c := newGitDeltaBaseCache(8)
+	c.add(10, 3, []byte("abc"))
+	c.add(20, 3, []byte("def"))
+
+	// Reading the first object makes the second the least recently used, and the one evicted by the third.
+	if objType, data, ok := c.get(10); !ok || objType != 3 || string(data) != "abc" {
+		t.Errorf("got object %d %q %t, want 3 \"abc\" true", objType, data, ok)
+	}
+	c.add(30, 3, []byte("ghi"))
+
+	for offset, want := range map[int64]bool{10: true, 20: false, 30: true} {
+		if _, _, ok := c.get(offset); ok != want {
+			t.Errorf("offset %d: got cached %t, want %t", offset, ok, want)
+		}
+	}
+
+	// Objects larger than the limit are not cached.
+	c.add(40, 3, []byte("too large"))
+	if _, _, ok := c.get(40); ok || c.size != 6 {
+		t.Errorf("expected object larger than the limit not to be cached, cache size is %d", c.size)
+	}
+}
+
+// createTestGitRepository creates a repository with a v1 tag, an annotated v1-annotated tag and a second commit on
+// main. The worktree also contains untracked and modified files which are not part of any commit.
+func createTestGitRepository(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	runGit(t, repo, "init", "--quiet", "--initial-branch=main")
+
+	writeTestFiles(t, repo, map[string]string{
+		"README.md":   "readme v1",
+		"src/main.py": testGitLargeFile("v1"),
+	})
+	runGit(t, repo, "add", "-A")
+	runGit(t, repo, "commit", "--quiet", "-m", "v1")
+	runGit(t, repo, "tag", "v1")
+	runGit(t, repo, "tag", "-a", "-m", "v1", "v1-annotated")
+
+	writeTestFiles(t, repo, map[string]string{
+		"README.md":       "readme v2",
+		"bin/run.sh":      "#!/bin/sh\necho run\n",
+		"src/main.py":     testGitLargeFile("v2"),
+		"src/pkg/util.py": "util",
+	})
+	if err := os.Chmod(filepath.Join(repo, "bin", "run.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("README.md", filepath.Join(repo, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "add", "-A")
+	runGit(t, repo, "commit", "--quiet", "-m", "v2")
+
+	writeTestFiles(t, repo, map[string]string{
+		"README.md":     "modified",
+		"untracked.txt": "untracked",
+	})
+
+	return repo
+}
+
+// testGitLargeFile returns content which git stores as a delta of the other versions when packing.
+func testGitLargeFile(version string) string {
+	return strings.Repeat("print('hello world')\n", 500) + version + "\n"
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_NOSYSTEM=1", "HOME="+dir,
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %s\n%s", strings.Join(args, " "), err, out)
+	}
+
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,170 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+const (
+	ExcludesSyntaxGlob      = "glob"
+	ExcludesSyntaxGitignore = "gitignore"
+)
+
+type gitignorePattern struct {
+	base    string
+	pattern string
+	negate  bool
+	dirOnly bool
+	// descendants is set for patterns ending with "/**", which match everything inside a directory but not the
+	// directory itself.
+	descendants bool
+}
+
+// gitignoreMatcher matches paths against an ordered list of patterns using the syntax of .gitignore files.
+type gitignoreMatcher struct {
+	patterns []gitignorePattern
+}
+
+func newGitignoreMatcher(lines []string) (*gitignoreMatcher, error) {
+	m := &gitignoreMatcher{}
+	if err := m.add("", lines); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// add appends the patterns of lines, scoped to the directory base which is the root of the archive when empty.
+func (m *gitignoreMatcher) add(base string, lines []string) error {
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		p := gitignorePattern{base: base}
+
+		switch {
+		case strings.HasPrefix(line, "!"):
+			p.negate = true
+			line = line[1:]
+		case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+
+		// A pattern containing a slash is relative to the directory of the patterns, other patterns match at any level.
+		if strings.Contains(line, "/") {
+			line = strings.TrimPrefix(line, "/")
+		} else {
+			line = "**/" + line
+		}
+
+		if strings.HasSuffix(line, "/**") {
+			p.descendants = true
+			line += "/*"
+		}
+
+		if line == "" || !doublestar.ValidatePattern(line) {
+			return fmt.Errorf("invalid exclude pattern: %s", line)
+		}
+
+		p.pattern = line
+		m.patterns = append(m.patterns, p)
+	}
+
+	return nil
+}
+
+// addFile appends the patterns of the ignore file filename, scoped to the directory base. It reports whether the
+// file exists.
+func (m *gitignoreMatcher) addFile(base, filename string) (bool, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error reading ignore file: %s", err)
+	}
+
+	if err := m.add(base, strings.Split(string(data), "\n")); err != nil {
+		return false, fmt.Errorf("error reading ignore file %s: %s", filename, err)
+	}
+
+	return true, nil
+}
+
+// excludes reports whether name is excluded. As with git, a path inside an excluded directory is always excluded,
+// otherwise the last pattern matching name decides. Directories matched by a pattern such as "dir/**" are the
+// exception: as the pattern also matches every path inside them, a later negated pattern can re-include those paths.
+func (m *gitignoreMatcher) excludes(name string, isDir bool) bool {
+	if name == "." {
+		return false
+	}
+
+	segments := strings.Split(filepath.ToSlash(name), "/")
+
+	for i := range segments {
+		last := i == len(segments)-1
+		j := m.lastMatch(strings.Join(segments[:i+1], "/"), isDir || !last)
+		if j < 0 || m.patterns[j].negate || (!last && m.patterns[j].descendants) {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// walksExcluded reports whether the excluded directory name must still be walked, because it is excluded by a
+// pattern such as "dir/**" which is followed by negated patterns that may re-include paths inside it.
+func (m *gitignoreMatcher) walksExcluded(name string) bool {
+	i := m.lastMatch(filepath.ToSlash(name), true)
+	if i < 0 || !m.patterns[i].descendants {
+		return false
+	}
+
+	for _, p := range m.patterns[i+1:] {
+		if p.negate {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lastMatch returns the index of the last pattern matching name, or -1 when no pattern matches it.
+func (m *gitignoreMatcher) lastMatch(name string, isDir bool) int {
+	for i := len(m.patterns) - 1; i >= 0; i-- {
+		p := m.patterns[i]
+		if p.dirOnly && !isDir {
+			continue
+		}
+
+		relname := name
+		if p.base != "" {
+			if !strings.HasPrefix(name, p.base+"/") {
+				continue
+			}
+			relname = strings.TrimPrefix(name, p.base+"/")
+		}
+
+		if isMatch, _ := doublestar.Match(p.pattern, relname); isMatch {
+			return i
+		}
+	}
+
+	return -1
+}
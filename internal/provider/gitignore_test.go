@@ -0,0 +1,144 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"testing"
+)
+
+func TestGitignoreMatcher(t *testing.T) {
+	m, err := newGitignoreMatcher([]string{
+		"# build output",
+		"*.log",
+		"!important.log",
+		"build/",
+		"/docs",
+		"cache/*",
+		"!cache/keep",
+		"config/*.json",
+		"\\#notes",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	testCases := []struct {
+		name  string
+		isDir bool
+		want  bool
+	}{
+		{name: ".", isDir: true, want: false},
+		{name: "app.log", want: true},
+		{name: "logs/app.log", want: true},
+		{name: "important.log", want: false},
+		{name: "logs/important.log", want: false},
+		{name: "build", isDir: true, want: true},
+		{name: "build", want: false},
+		{name: "src/build", isDir: true, want: true},
+		{name: "src/build/main.o", want: true},
+		{name: "docs", isDir: true, want: true},
+		{name: "docs/index.md", want: true},
+		{name: "src/docs", isDir: true, want: false},
+		{name: "cache/data", want: true},
+		{name: "cache/keep", want: false},
+		{name: "config/app.json", want: true},
+		{name: "config/env/app.json", want: false},
+		{name: "#notes", want: true},
+		{name: "main.go", want: false},
+	}
+
+	for _, tc := range testCases {
+		if got := m.excludes(tc.name, tc.isDir); got != tc.want {
+			t.Errorf("excludes(%q, %t) = %t, want %t", tc.name, tc.isDir, got, tc.want)
+		}
+	}
+}
+
+func TestGitignoreMatcher_LastMatchWins(t *testing.T) {
+	testCases := []struct {
+		patterns []string
+		want     bool
+	}{
+		{patterns: []string{"*.txt", "!notes.txt"}, want: false},
+		{patterns: []string{"!notes.txt", "*.txt"}, want: true},
+		{patterns: []string{"*.txt", "!notes.txt", "notes.*"}, want: true},
+	}
+
+	for _, tc := range testCases {
+		m, err := newGitignoreMatcher(tc.patterns)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if got := m.excludes("notes.txt", false); got != tc.want {
+			t.Errorf("patterns %q: excludes(%q) = %t, want %t", tc.patterns, "notes.txt", got, tc.want)
+		}
+	}
+}
+
+func TestGitignoreMatcher_ExcludedParent(t *testing.T) {
+	m, err := newGitignoreMatcher([]string{"node_modules/", "!node_modules/.bin/mytool"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// As with git, a file cannot be re-included when its parent directory is excluded.
+	if !m.excludes("node_modules/.bin/mytool", false) {
+		t.Errorf("expected node_modules/.bin/mytool to be excluded")
+	}
+}
+
+func TestGitignoreMatcher_Descendants(t *testing.T) {
+	testCases := map[string][]string{
+		"file":      {"node_modules/**", "!node_modules/.bin/mytool"},
+		"directory": {"node_modules/**", "!node_modules/.bin/", "!node_modules/.bin/mytool"},
+	}
+
+	for name, patterns := range testCases {
+		t.Run(name, func(t *testing.T) {
+			m, err := newGitignoreMatcher(patterns)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			excludes := map[string]bool{
+				"node_modules/.bin/mytool":     false,
+				"node_modules/.bin/other":      true,
+				"node_modules/pkg/index.js":    true,
+				"node_modules/pkg/lib/util.js": true,
+				"src/node_modules/index.js":    false,
+			}
+			for path, want := range excludes {
+				if got := m.excludes(path, false); got != want {
+					t.Errorf("excludes(%q) = %t, want %t", path, got, want)
+				}
+			}
+
+			// The directory itself, and a file named like it, are not matched by "node_modules/**".
+			if m.excludes("node_modules", true) || m.excludes("node_modules", false) {
+				t.Errorf("expected node_modules not to be excluded")
+			}
+
+			if !m.walksExcluded("node_modules/.bin") && m.excludes("node_modules/.bin", true) {
+				t.Errorf("expected node_modules/.bin to be walked")
+			}
+		})
+	}
+
+	m, err := newGitignoreMatcher([]string{"node_modules/**"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Without negated patterns, excluded directories are skipped as a whole.
+	if !m.excludes("node_modules/pkg", true) || m.walksExcluded("node_modules/pkg") {
+		t.Errorf("expected node_modules/pkg to be excluded without being walked")
+	}
+}
+
+func TestGitignoreMatcher_InvalidPattern(t *testing.T) {
+	if _, err := newGitignoreMatcher([]string{"[abc"}); err == nil {
+		t.Fatalf("expected error for invalid pattern")
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+// applyHardLinks makes the entries read from a file which is a hard link to the file of an entry before them hard
+// links to that entry, so that tar archives store the content of the file once. Hard links are only detected on
+// Unix systems.
+func applyHardLinks(entries []ArchiveEntry) {
+	names := make(map[fileID]string)
+
+	for i := range entries {
+		entry := &entries[i]
+		if entry.SourceInfo == nil || !entry.SourceInfo.Mode().IsRegular() || entry.LinkTarget != "" {
+			continue
+		}
+
+		id, ok := hardLinkID(entry.SourceInfo)
+		if !ok {
+			continue
+		}
+
+		if name, ok := names[id]; ok {
+			entry.HardLinkTarget = name
+			continue
+		}
+		names[id] = entry.Name
+	}
+}
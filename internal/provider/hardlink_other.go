@@ -0,0 +1,17 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !unix
+
+package archive
+
+import (
+	"os"
+)
+
+type fileID struct{}
+
+// hardLinkID never identifies hard links, as they are only detected on Unix systems.
+func hardLinkID(os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
@@ -0,0 +1,27 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build unix
+
+package archive
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID identifies a file by its device and inode numbers.
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// hardLinkID returns the ID of the file described by info when it has more than one hard link.
+func hardLinkID(info os.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || stat.Nlink < 2 {
+		return fileID{}, false
+	}
+
+	return fileID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true //nolint:unconvert // The types vary by platform.
+}
@@ -0,0 +1,120 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build unix
+
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	r "github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestApplyHardLinks(t *testing.T) {
+	dir := createTestHardLinksDir(t)
+
+	entries, err := walkDir(dir, ArchiveDirOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	entries = append(entries, ArchiveEntry{Name: "config.json", Content: []byte("{}")})
+
+	applyHardLinks(entries)
+
+	got := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		got[entry.Name] = entry.HardLinkTarget
+	}
+
+	// The first file of the walk is archived as a file, and the others as hard links to it.
+	want := map[string]string{
+		"a/libfoo.so":   "",
+		"b/libfoo.so":   "a/libfoo.so",
+		"c/libfoo.so.1": "a/libfoo.so",
+		"main.py":       "",
+		"main_copy.py":  "",
+		"config.json":   "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got hard links %q, want %q", got, want)
+	}
+}
+
+func TestResource_PreserveHardlinks(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "tar_file_acc_test_preserve_hardlinks.tar.gz")
+	dir := createTestHardLinksDir(t)
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type               = "zip"
+  source_dir         = "%s"
+  output_path        = "%s"
+  preserve_hardlinks = true
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`preserve_hardlinks\s+is\s+not\s+supported\s+by\s+zip\s+archives`),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type               = "tar.gz"
+  source_dir         = "%s"
+  output_path        = "%s"
+  preserve_hardlinks = true
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					headers := tarHeaders(t, value)
+
+					if h := headers["a/libfoo.so"]; h.Typeflag != tar.TypeReg || h.Size != int64(len("lib")) {
+						return fmt.Errorf("expected a/libfoo.so to be a file, got %+v", h)
+					}
+					for _, name := range []string{"b/libfoo.so", "c/libfoo.so.1"} {
+						if h := headers[name]; h.Typeflag != tar.TypeLink || h.Linkname != "a/libfoo.so" {
+							return fmt.Errorf("expected %s to be a hard link to a/libfoo.so, got %+v", name, h)
+						}
+					}
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+// createTestHardLinksDir creates a directory with three hard links to the same file, and a copy of another file.
+func createTestHardLinksDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a/libfoo.so":  "lib",
+		"main.py":      "main",
+		"main_copy.py": "main",
+	})
+
+	for _, name := range []string{"b/libfoo.so", "c/libfoo.so.1"} {
+		link := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Link(filepath.Join(dir, "a", "libfoo.so"), link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
@@ -0,0 +1,126 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	MtimeModeZero            = "zero"
+	MtimeModeFixed           = "fixed"
+	MtimeModeSourceDateEpoch = "source_date_epoch"
+	MtimeModePreserve        = "preserve"
+)
+
+// minMtime is the earliest modification time zip archives can record.
+var minMtime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// applyModTimes sets the modification time of entries according to the MtimeMode* mode. Entries which already have
+// a modification time, such as those of entry blocks with a mtime, are left unchanged, and so are all entries with
+// MtimeModeZero. Times before minMtime are an error for zip archives, except those preserved from the files.
+func applyModTimes(entries []ArchiveEntry, archiveType, mode string, mtime time.Time) error {
+	if !mtime.IsZero() && mode != MtimeModeFixed {
+		return fmt.Errorf("mtime is only used when mtime_mode is %q", MtimeModeFixed)
+	}
+
+	if archiveType == "zip" {
+		for _, entry := range entries {
+			if !entry.ModTime.IsZero() {
+				if err := checkZipModTime(entry.Name, entry.ModTime); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	switch mode {
+	case "", MtimeModeZero:
+		return nil
+	case MtimeModeFixed:
+		if mtime.IsZero() {
+			return fmt.Errorf("mtime must be set when mtime_mode is %q", MtimeModeFixed)
+		}
+	case MtimeModeSourceDateEpoch:
+		var err error
+		mtime, err = sourceDateEpoch()
+		if err != nil {
+			return err
+		}
+	case MtimeModePreserve:
+	default:
+		return fmt.Errorf("invalid mtime_mode: %s", mode)
+	}
+
+	if archiveType == "zip" && mode != MtimeModePreserve {
+		if err := checkZipModTime("", mtime); err != nil {
+			return err
+		}
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		if !entry.ModTime.IsZero() {
+			continue
+		}
+
+		if mode == MtimeModePreserve {
+			// Entries with content of their own, rather than a file, have no modification time to preserve.
+			if entry.SourceInfo != nil {
+				// Archives record modification times to the second at best.
+				entry.ModTime = entry.SourceInfo.ModTime().Truncate(time.Second).UTC()
+			}
+			continue
+		}
+
+		entry.ModTime = mtime.UTC()
+	}
+
+	return nil
+}
+
+// checkZipModTime reports modification times which zip archives cannot record, for the file name if any or for every
+// file otherwise.
+func checkZipModTime(name string, mtime time.Time) error {
+	if !mtime.Before(minMtime) {
+		return nil
+	}
+
+	if name != "" {
+		return fmt.Errorf("modification time %s of %s is before %s, the earliest time zip archives can record",
+			mtime.Format(time.RFC3339), name, minMtime.Format(time.RFC3339))
+	}
+
+	return fmt.Errorf("modification time %s is before %s, the earliest time zip archives can record",
+		mtime.Format(time.RFC3339), minMtime.Format(time.RFC3339))
+}
+
+// sourceDateEpoch returns the time set by the SOURCE_DATE_EPOCH environment variable, as described by
+// https://reproducible-builds.org/specs/source-date-epoch/.
+func sourceDateEpoch() (time.Time, error) {
+	value, ok := os.LookupEnv("SOURCE_DATE_EPOCH")
+	if !ok || value == "" {
+		return time.Time{}, fmt.Errorf("SOURCE_DATE_EPOCH must be set when mtime_mode is %q", MtimeModeSourceDateEpoch)
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: must be a number of seconds since the Unix epoch", value)
+	}
+
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// parseMtime parses an RFC3339 timestamp such as "2024-01-01T00:00:00Z".
+func parseMtime(mtime string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, mtime)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid mtime %q: must be an RFC3339 timestamp", mtime)
+	}
+
+	return t, nil
+}
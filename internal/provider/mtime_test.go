@@ -0,0 +1,110 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyModTimes(t *testing.T) {
+	fixed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	onDisk := time.Date(2023, 6, 15, 12, 30, 45, 123456789, time.UTC)
+	entryTime := time.Date(2022, 2, 2, 0, 0, 0, 0, time.UTC)
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	testCases := map[string]struct {
+		mode  string
+		mtime time.Time
+		file  time.Time
+		blob  time.Time
+	}{
+		MtimeModeZero:            {},
+		MtimeModeFixed:           {mtime: fixed, file: fixed, blob: fixed},
+		MtimeModeSourceDateEpoch: {file: time.Unix(1700000000, 0).UTC(), blob: time.Unix(1700000000, 0).UTC()},
+		MtimeModePreserve:        {file: onDisk.Truncate(time.Second)},
+	}
+
+	for mode, tc := range testCases {
+		entries := []ArchiveEntry{
+			{Name: "main.py", SourceInfo: testFileInfo{modTime: onDisk}},
+			{Name: "config.json", Content: []byte("{}")},
+			{Name: "bin/run", Content: []byte("#!/bin/sh"), ModTime: entryTime},
+		}
+
+		if err := applyModTimes(entries, "zip", mode, tc.mtime); err != nil {
+			t.Fatalf("%s: unexpected error: %s", mode, err)
+		}
+
+		for i, want := range []time.Time{tc.file, tc.blob, entryTime} {
+			if got := entries[i].ModTime; !got.Equal(want) {
+				t.Errorf("%s: %s: got mtime %s, want %s", mode, entries[i].Name, got, want)
+			}
+		}
+	}
+}
+
+func TestApplyModTimes_Invalid(t *testing.T) {
+	fixed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		mode  string
+		mtime time.Time
+		epoch string
+	}{
+		"fixed without mtime":     {mode: MtimeModeFixed},
+		"fixed before 1980":       {mode: MtimeModeFixed, mtime: time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)},
+		"mtime without fixed":     {mode: MtimeModePreserve, mtime: fixed},
+		"unset SOURCE_DATE_EPOCH": {mode: MtimeModeSourceDateEpoch},
+		"invalid SOURCE_DATE_EPOCH": {
+			mode:  MtimeModeSourceDateEpoch,
+			epoch: "2024-01-01",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("SOURCE_DATE_EPOCH", tc.epoch)
+
+			if err := applyModTimes([]ArchiveEntry{{Name: "main.py"}}, "zip", tc.mode, tc.mtime); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestApplyModTimes_BeforeZip(t *testing.T) {
+	epoch := time.Unix(0, 0).UTC()
+
+	t.Setenv("SOURCE_DATE_EPOCH", "0")
+
+	// Tar archives record times before 1980, which zip archives cannot.
+	for mode, mtime := range map[string]time.Time{
+		MtimeModeFixed:           epoch,
+		MtimeModeSourceDateEpoch: {},
+	} {
+		entries := []ArchiveEntry{{Name: "main.py", Content: []byte("main")}}
+		if err := applyModTimes(entries, "tar.gz", mode, mtime); err != nil {
+			t.Errorf("%s: unexpected error for tar.gz: %s", mode, err)
+		} else if !entries[0].ModTime.Equal(epoch) {
+			t.Errorf("%s: got mtime %s, want %s", mode, entries[0].ModTime, epoch)
+		}
+
+		if err := applyModTimes([]ArchiveEntry{{Name: "main.py"}}, "zip", mode, mtime); err == nil {
+			t.Errorf("%s: expected error for zip", mode)
+		}
+	}
+
+	// The mtime of entry blocks is checked as well, whatever mtime_mode.
+	entries := []ArchiveEntry{{Name: "bin/run", Content: []byte("#!/bin/sh"), ModTime: epoch}}
+	if err := applyModTimes(entries, "tar.gz", MtimeModeZero, time.Time{}); err != nil {
+		t.Errorf("unexpected error for tar.gz: %s", err)
+	}
+	err := applyModTimes(entries, "zip", MtimeModeZero, time.Time{})
+	if err == nil || !strings.Contains(err.Error(), "bin/run") {
+		t.Errorf("expected error for the mtime of bin/run, got: %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const nodeModulesDir = "node_modules"
+
+type packageLock struct {
+	LockfileVersion int                           `json:"lockfileVersion"`
+	Packages        map[string]packageLockPackage `json:"packages"`
+}
+
+type packageLockPackage struct {
+	Dev bool `json:"dev"`
+}
+
+// nodeModulesFilter decides which paths below node_modules belong to the production dependency graph recorded in a
+// package-lock.json.
+type nodeModulesFilter struct {
+	dir      string
+	packages map[string]packageLockPackage
+}
+
+func newNodeModulesFilter(indirname string) (*nodeModulesFilter, error) {
+	lockPath := filepath.Join(indirname, "package-lock.json")
+
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("could not prune dev dependencies, missing package-lock.json: %s", lockPath)
+		}
+		return nil, err
+	}
+
+	var lock packageLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", lockPath, err)
+	}
+
+	if lock.LockfileVersion < 2 || lock.Packages == nil {
+		return nil, fmt.Errorf("could not prune dev dependencies, unsupported lockfileVersion %d in %s (2 or 3 required)",
+			lock.LockfileVersion, lockPath)
+	}
+
+	return &nodeModulesFilter{
+		dir:      indirname,
+		packages: lock.Packages,
+	}, nil
+}
+
+// excludes reports whether the path, relative to the directory containing package-lock.json, should be left out of
+// the archive because it does not belong to a production dependency.
+func (f *nodeModulesFilter) excludes(relname string, isDir bool) bool {
+	name := filepath.ToSlash(relname)
+	if name != nodeModulesDir && !strings.HasPrefix(name, nodeModulesDir+"/") {
+		return false
+	}
+
+	// The executables of packages are linked from node_modules/.bin, and are kept like `npm ci --omit=dev` does
+	// when they belong to a production package.
+	if !isDir && path.Base(path.Dir(name)) == ".bin" {
+		return !f.isProductionBin(name)
+	}
+
+	key := packageKey(name)
+	if key == "" {
+		// Directories such as node_modules or node_modules/@scope need to be walked to reach the packages inside them,
+		// but loose files such as node_modules/.package-lock.json are not part of any package.
+		return !isDir
+	}
+
+	if f.isProduction(key) {
+		return false
+	}
+
+	// Keep walking a pruned directory if a production package is nested inside of it.
+	if isDir && f.hasProductionPackageBelow(name) {
+		return false
+	}
+
+	return true
+}
+
+func (f *nodeModulesFilter) isProduction(key string) bool {
+	pkg, ok := f.packages[key]
+	return ok && !pkg.Dev
+}
+
+// isProductionBin reports whether name, a file of a .bin directory, is a symbolic link to a file of a production
+// package.
+func (f *nodeModulesFilter) isProductionBin(name string) bool {
+	target, err := os.Readlink(filepath.Join(f.dir, filepath.FromSlash(name)))
+	if err != nil || filepath.IsAbs(target) {
+		return false
+	}
+
+	key := packageKey(path.Join(path.Dir(name), filepath.ToSlash(target)))
+	return key != "" && f.isProduction(key)
+}
+
+func (f *nodeModulesFilter) hasProductionPackageBelow(dir string) bool {
+	prefix := dir + "/"
+	for k, pkg := range f.packages {
+		if !pkg.Dev && strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// packageKey returns the package-lock.json key of the innermost package containing name, for example
+// node_modules/a/node_modules/@scope/b for node_modules/a/node_modules/@scope/b/index.js, or an empty string when
+// name is not inside a package.
+func packageKey(name string) string {
+	segments := strings.Split(name, "/")
+
+	key := ""
+	for i := 0; i < len(segments) && segments[i] == nodeModulesDir; {
+		i++
+		if i >= len(segments) || strings.HasPrefix(segments[i], ".") {
+			break
+		}
+
+		if strings.HasPrefix(segments[i], "@") {
+			i++
+			if i >= len(segments) {
+				break
+			}
+		}
+		i++
+
+		key = strings.Join(segments[:i], "/")
+	}
+
+	return key
+}
@@ -0,0 +1,146 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPackageKey(t *testing.T) {
+	cases := map[string]string{
+		"index.js":                                     "",
+		"node_modules":                                 "",
+		"node_modules/.package-lock.json":              "",
+		"node_modules/.bin/jest":                       "",
+		"node_modules/@aws-sdk":                        "",
+		"node_modules/lodash":                          "node_modules/lodash",
+		"node_modules/lodash/index.js":                 "node_modules/lodash",
+		"node_modules/@aws-sdk/client-s3/package.json": "node_modules/@aws-sdk/client-s3",
+		"node_modules/a/node_modules":                  "node_modules/a",
+		"node_modules/a/node_modules/@s/b/lib/b.js":    "node_modules/a/node_modules/@s/b",
+	}
+
+	for name, want := range cases {
+		if got := packageKey(name); got != want {
+			t.Errorf("packageKey(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestNodeModulesFilter_UnsupportedLockfileVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"package-lock.json": `{"lockfileVersion": 1, "dependencies": {}}`,
+	})
+
+	_, err := newNodeModulesFilter(dir)
+	if err == nil || !strings.Contains(err.Error(), "unsupported lockfileVersion 1") {
+		t.Fatalf("expected unsupported lockfileVersion error, got: %v", err)
+	}
+}
+
+func TestNodeModulesFilter_MissingLockfile(t *testing.T) {
+	_, err := newNodeModulesFilter(t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "missing package-lock.json") {
+		t.Fatalf("expected missing package-lock.json error, got: %v", err)
+	}
+}
+
+func TestNodeModulesFilter_Bins(t *testing.T) {
+	dir := createTestNodeProject(t)
+	writeTestFiles(t, dir, map[string]string{
+		"package-lock.json": `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "app"},
+    "node_modules/uuid": {"version": "9.0.0", "bin": {"uuid": "dist/bin/uuid"}},
+    "node_modules/uuid/node_modules/tool": {"version": "1.0.0", "bin": {"tool": "cli.js"}},
+    "node_modules/jest": {"version": "29.7.0", "dev": true, "bin": {"jest": "bin/jest.js"}}
+  }
+}`,
+		"node_modules/uuid/dist/bin/uuid":            "uuid",
+		"node_modules/uuid/node_modules/tool/cli.js": "tool",
+		"node_modules/jest/bin/jest.js":              "jest",
+	})
+	if err := os.Remove(filepath.Join(dir, "node_modules", ".bin", "jest")); err != nil {
+		t.Fatal(err)
+	}
+	createTestSymlink(t, "../uuid/dist/bin/uuid", filepath.Join(dir, "node_modules", ".bin", "uuid"))
+	createTestSymlink(t, "../jest/bin/jest.js", filepath.Join(dir, "node_modules", ".bin", "jest"))
+	createTestSymlink(t, "../tool/cli.js", filepath.Join(dir, "node_modules", "uuid", "node_modules", ".bin", "tool"))
+
+	entries, err := walkDir(dir, ArchiveDirOpts{
+		Excludes:                 []string{"package-lock.json", "index.js"},
+		NodePruneDevDependencies: true,
+		SymlinkMode:              SymlinkModePreserve,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		got[entry.Name] = entry.LinkTarget
+	}
+
+	want := map[string]string{
+		"node_modules/.bin/uuid":                     "../uuid/dist/bin/uuid",
+		"node_modules/uuid/dist/bin/uuid":            "",
+		"node_modules/uuid/node_modules/.bin/tool":   "../tool/cli.js",
+		"node_modules/uuid/node_modules/tool/cli.js": "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got files %q, want %q", got, want)
+	}
+}
+
+// createTestNodeProject writes a project with production and development dependencies installed into node_modules.
+func createTestNodeProject(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"index.js": "require('lodash')",
+		"package-lock.json": `{
+  "name": "app",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "app", "dependencies": {"lodash": "^4.0.0"}, "devDependencies": {"jest": "^29.0.0"}},
+    "node_modules/lodash": {"version": "4.17.21"},
+    "node_modules/jest": {"version": "29.7.0", "dev": true},
+    "node_modules/jest/node_modules/@jest/core": {"version": "29.7.0", "dev": true},
+    "node_modules/@types/node": {"version": "20.0.0", "dev": true},
+    "node_modules/@smithy/types": {"version": "2.0.0"}
+  }
+}`,
+		"node_modules/.package-lock.json":                    "{}",
+		"node_modules/.bin/jest":                             "#!/usr/bin/env node",
+		"node_modules/lodash/index.js":                       "lodash",
+		"node_modules/jest/index.js":                         "jest",
+		"node_modules/jest/node_modules/@jest/core/index.js": "jest core",
+		"node_modules/@types/node/index.d.ts":                "types",
+		"node_modules/@smithy/types/index.js":                "smithy",
+		"node_modules/extraneous/index.js":                   "not in lockfile",
+	})
+
+	return dir
+}
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// ownerRegexp matches an owner or group given as ID, NAME or NAME:ID, like the --owner and --group options of GNU
+// tar.
+var ownerRegexp = regexp.MustCompile(`^(\d+|[^:\s]+(:\d+)?)$`)
+
+// ArchiveOwner is the user or the group owning an archived file, recorded by tar archives.
+type ArchiveOwner struct {
+	ID   int
+	Name string
+}
+
+// OwnerRule sets the owner and the group of the files whose path inside the archive matches Pattern. Each of them is
+// left to the defaults of OwnerOpts when nil.
+type OwnerRule struct {
+	Pattern string
+	Owner   *ArchiveOwner
+	Group   *ArchiveOwner
+}
+
+type OwnerOpts struct {
+	Owner ArchiveOwner
+	Group ArchiveOwner
+	// Rules are evaluated in order, and the first rule matching a file sets its owner and group.
+	Rules []OwnerRule
+}
+
+// applyOwners sets the owner and the group of entries according to opts.
+func applyOwners(entries []ArchiveEntry, opts OwnerOpts) error {
+	for _, rule := range opts.Rules {
+		if !doublestar.ValidatePattern(rule.Pattern) {
+			return fmt.Errorf("invalid owner rule pattern: %s", rule.Pattern)
+		}
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		entry.Owner = opts.Owner
+		entry.Group = opts.Group
+
+		for _, rule := range opts.Rules {
+			if isMatch, _ := doublestar.Match(rule.Pattern, entry.Name); !isMatch {
+				continue
+			}
+
+			if rule.Owner != nil {
+				entry.Owner = *rule.Owner
+			}
+			if rule.Group != nil {
+				entry.Group = *rule.Group
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+// parseOwner parses an owner or a group given as ID, NAME or NAME:ID. A name without an ID is recorded with the ID 0,
+// as names are not looked up on the host to keep archives reproducible.
+func parseOwner(owner string) (ArchiveOwner, error) {
+	if !ownerRegexp.MatchString(owner) {
+		return ArchiveOwner{}, fmt.Errorf("invalid owner %q: must be an ID, a name or a name:ID pair", owner)
+	}
+
+	if id, err := strconv.Atoi(owner); err == nil {
+		return ArchiveOwner{ID: id}, nil
+	}
+
+	name, id, ok := strings.Cut(owner, ":")
+	if !ok {
+		return ArchiveOwner{Name: name}, nil
+	}
+
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return ArchiveOwner{}, fmt.Errorf("invalid owner %q: %s", owner, err)
+	}
+
+	return ArchiveOwner{ID: n, Name: name}, nil
+}
@@ -0,0 +1,75 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"testing"
+)
+
+func TestParseOwner(t *testing.T) {
+	testCases := map[string]ArchiveOwner{
+		"1000":     {ID: 1000},
+		"0":        {},
+		"root":     {Name: "root"},
+		"app:1000": {ID: 1000, Name: "app"},
+		"www-data": {Name: "www-data"},
+	}
+
+	for value, want := range testCases {
+		got, err := parseOwner(value)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", value, err)
+		}
+		if got != want {
+			t.Errorf("%s: got %+v, want %+v", value, got, want)
+		}
+	}
+
+	for _, value := range []string{"", "app:", ":1000", "app:x", "a b", "app:1000:1000"} {
+		if _, err := parseOwner(value); err == nil {
+			t.Errorf("%q: expected error", value)
+		}
+	}
+}
+
+func TestApplyOwners(t *testing.T) {
+	app := ArchiveOwner{ID: 1000, Name: "app"}
+	root := ArchiveOwner{Name: "root"}
+
+	entries := []ArchiveEntry{
+		{Name: "main.py"},
+		{Name: "bin/run"},
+		{Name: "bin/tool"},
+		{Name: "etc/config.json"},
+	}
+
+	err := applyOwners(entries, OwnerOpts{
+		Owner: app,
+		Group: app,
+		Rules: []OwnerRule{
+			{Pattern: "bin/run", Owner: &root},
+			{Pattern: "bin/**", Owner: &root, Group: &root},
+			{Pattern: "etc/**", Group: &root},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := map[string][2]ArchiveOwner{
+		"main.py":         {app, app},
+		"bin/run":         {root, app},
+		"bin/tool":        {root, root},
+		"etc/config.json": {app, root},
+	}
+	for _, entry := range entries {
+		if got := [2]ArchiveOwner{entry.Owner, entry.Group}; got != want[entry.Name] {
+			t.Errorf("%s: got owner and group %+v, want %+v", entry.Name, got, want[entry.Name])
+		}
+	}
+
+	if err := applyOwners(entries, OwnerOpts{Rules: []OwnerRule{{Pattern: "bin/["}}}); err == nil {
+		t.Errorf("expected error for invalid pattern")
+	}
+}
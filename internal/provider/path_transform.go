@@ -0,0 +1,181 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PathTransform renames the files whose slash separated path matches Match, like `tar --transform` does. Every match
+// is replaced with Replace, in which $1 or ${name} refer to the submatches of Match.
+type PathTransform struct {
+	Match   *regexp.Regexp
+	Replace string
+}
+
+// pathCollisionError reports files which would be archived under the same name once renamed by path transforms, strip
+// components or flatten.
+type pathCollisionError struct {
+	// collisions are the files archived under each name which is used more than once.
+	collisions map[string][]string
+}
+
+func (e *pathCollisionError) Error() string {
+	names := make([]string, 0, len(e.collisions))
+	for name := range e.collisions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = fmt.Sprintf("%s (from %s)", name, strings.Join(e.collisions[name], ", "))
+	}
+
+	return fmt.Sprintf("files would be archived under the same name, set on_collision to keep one of them:\n  %s",
+		strings.Join(lines, "\n  "))
+}
+
+// pathCollisions tracks the files archived under each name once renamed, so that names used more than once are
+// resolved with an OnCollision* policy.
+type pathCollisions struct {
+	policy  string
+	sources map[string][]string
+}
+
+// add records that source is archived as name, and reports whether it should be archived, replacing the file
+// already archived as name if there is one.
+func (c *pathCollisions) add(name, source string) bool {
+	if c.sources == nil {
+		c.sources = make(map[string][]string)
+	}
+
+	c.sources[name] = append(c.sources[name], source)
+	return len(c.sources[name]) == 1 || c.policy == OnCollisionLast
+}
+
+func (c *pathCollisions) err() error {
+	if c.policy == OnCollisionFirst || c.policy == OnCollisionLast {
+		return nil
+	}
+
+	collisions := make(map[string][]string)
+	for name, sources := range c.sources {
+		if len(sources) > 1 {
+			collisions[name] = sources
+		}
+	}
+	if len(collisions) == 0 {
+		return nil
+	}
+
+	return &pathCollisionError{collisions: collisions}
+}
+
+// transformPath applies transforms to name in order, each to the result of the previous one.
+func transformPath(name string, transforms []PathTransform) (string, error) {
+	return cleanTransformedPath(name, applyTransforms(name, transforms))
+}
+
+// transformDirPath applies transforms to the directory name followed by a slash, so that the transforms renaming
+// the directories of files rename the directories themselves, and returns false when the transforms remove it.
+func transformDirPath(name string, transforms []PathTransform) (string, bool, error) {
+	transformed := applyTransforms(name+"/", transforms)
+	if strings.Trim(transformed, "/") == "" {
+		return "", false, nil
+	}
+
+	cleaned, err := cleanTransformedPath(name, transformed)
+	return cleaned, err == nil, err
+}
+
+func applyTransforms(name string, transforms []PathTransform) string {
+	for _, transform := range transforms {
+		name = transform.Match.ReplaceAllString(name, transform.Replace)
+	}
+
+	return name
+}
+
+// cleanTransformedPath cleans the path name is transformed to, which must stay inside the archive.
+func cleanTransformedPath(name, transformed string) (string, error) {
+	cleaned := path.Clean(transformed)
+	if transformed == "" || cleaned == "." || path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("path_transform renames %s to invalid path %q", name, transformed)
+	}
+
+	return cleaned, nil
+}
+
+// transformContent renames the files of content with transforms, resolving the names used more than once with the
+// onCollision policy in the order of the original names.
+func transformContent(content map[string][]byte, transforms []PathTransform, onCollision string) (map[string][]byte, error) {
+	if len(transforms) == 0 {
+		return content, nil
+	}
+
+	// Rename files in the same order so that collisions are always resolved the same way.
+	names := make([]string, 0, len(content))
+	for name := range content {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	collisions := pathCollisions{policy: onCollision}
+	transformed := make(map[string][]byte, len(content))
+	for _, name := range names {
+		newName, err := transformPath(name, transforms)
+		if err != nil {
+			return nil, err
+		}
+
+		if collisions.add(newName, name) {
+			transformed[newName] = content[name]
+		}
+	}
+
+	if err := collisions.err(); err != nil {
+		return nil, err
+	}
+
+	return transformed, nil
+}
+
+// resolvePathCollisions resolves the names used by more than one file of the same directory with the onCollision
+// policy, in the order the files are walked. This can only happen once files are renamed by path transforms, strip
+// components or flatten.
+func resolvePathCollisions(entries []ArchiveEntry, onCollision string) ([]ArchiveEntry, error) {
+	collisions := pathCollisions{policy: onCollision}
+	indexes := make(map[string]int, len(entries))
+
+	var resolved []ArchiveEntry
+	for _, entry := range entries {
+		// Directories renamed to the same name are archived once.
+		if i, ok := indexes[entry.Name]; ok && entry.isDir() && resolved[i].isDir() {
+			continue
+		}
+
+		if !collisions.add(entry.Name, entry.SourcePath) {
+			continue
+		}
+
+		if i, ok := indexes[entry.Name]; ok {
+			resolved[i] = entry
+			continue
+		}
+
+		indexes[entry.Name] = len(resolved)
+		resolved = append(resolved, entry)
+	}
+
+	if err := collisions.err(); err != nil {
+		return nil, err
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,146 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestTransformPath(t *testing.T) {
+	testCases := []struct {
+		name       string
+		transforms []PathTransform
+		want       string
+	}{
+		{
+			name:       "dist/index.js",
+			transforms: []PathTransform{{Match: regexp.MustCompile(`^dist/`)}},
+			want:       "index.js",
+		},
+		{
+			name:       "config/app.prod.json",
+			transforms: []PathTransform{{Match: regexp.MustCompile(`^(.*)\.prod\.json$`), Replace: "$1.json"}},
+			want:       "config/app.json",
+		},
+		{
+			name:       "bin/run.sh",
+			transforms: []PathTransform{{Match: regexp.MustCompile(`^bin/(?P<file>[^/]+)$`), Replace: "${file}"}},
+			want:       "run.sh",
+		},
+		{
+			name:       "src/a/b.py",
+			transforms: []PathTransform{{Match: regexp.MustCompile(`^lib/`)}},
+			want:       "src/a/b.py",
+		},
+		{
+			// Transforms apply to the result of the previous one.
+			name: "dist/app.prod.json",
+			transforms: []PathTransform{
+				{Match: regexp.MustCompile(`^dist/`), Replace: "build/"},
+				{Match: regexp.MustCompile(`^build/(.*)\.prod`), Replace: "$1"},
+			},
+			want: "app.json",
+		},
+	}
+
+	for _, tc := range testCases {
+		got, err := transformPath(tc.name, tc.transforms)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", tc.name, err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTransformPath_Invalid(t *testing.T) {
+	for _, replace := range []string{"", "/etc/passwd", "../evil.txt", "a/../.."} {
+		transforms := []PathTransform{{Match: regexp.MustCompile(`^.*$`), Replace: replace}}
+
+		if _, err := transformPath("main.py", transforms); err == nil {
+			t.Errorf("replace %q: expected error", replace)
+		}
+	}
+}
+
+func TestTransformContent(t *testing.T) {
+	transforms := []PathTransform{{Match: regexp.MustCompile(`\.prod\.json$`), Replace: ".json"}}
+
+	got, err := transformContent(map[string][]byte{
+		"app.prod.json": []byte("prod"),
+		"main.py":       []byte("main"),
+	}, transforms, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := map[string][]byte{
+		"app.json": []byte("prod"),
+		"main.py":  []byte("main"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	colliding := map[string][]byte{
+		"app.json":      []byte("dev"),
+		"app.prod.json": []byte("prod"),
+	}
+
+	_, err = transformContent(colliding, transforms, OnCollisionError)
+
+	var collision *pathCollisionError
+	if !errors.As(err, &collision) || collision.collisions["app.json"] == nil {
+		t.Fatalf("expected collision on app.json, got: %v", err)
+	}
+
+	// Files are renamed in the order of their original names.
+	for policy, want := range map[string]string{OnCollisionFirst: "dev", OnCollisionLast: "prod"} {
+		got, err := transformContent(colliding, transforms, policy)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", policy, err)
+		}
+		if len(got) != 1 || string(got["app.json"]) != want {
+			t.Errorf("%s: got %q, want app.json with %q", policy, got, want)
+		}
+	}
+}
+
+func TestWalkDir_PathTransforms(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"dist/index.js":         "index",
+		"dist/config.prod.json": "prod",
+		"bin/run.sh":            "run",
+		"README.md":             "readme",
+	})
+
+	transforms := []PathTransform{
+		{Match: regexp.MustCompile(`^(dist|bin)/`)},
+		{Match: regexp.MustCompile(`\.prod\.json$`), Replace: ".json"},
+	}
+
+	entries, err := walkDir(dir, ArchiveDirOpts{TargetPrefix: "app", PathTransforms: transforms})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{"app/README.md", "app/config.json", "app/index.js", "app/run.sh"}
+	if got := entryNames(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("got files %q, want %q", got, want)
+	}
+
+	writeTestFiles(t, dir, map[string]string{"index.js": "root"})
+
+	_, err = walkDir(dir, ArchiveDirOpts{PathTransforms: transforms})
+
+	var collision *pathCollisionError
+	if !errors.As(err, &collision) || collision.collisions["index.js"] == nil {
+		t.Fatalf("expected collision on index.js, got: %v", err)
+	}
+}
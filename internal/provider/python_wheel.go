@@ -0,0 +1,337 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type PythonWheelOpts struct {
+	TargetPrefix      string
+	SkipRecordRewrite bool
+}
+
+// installPythonWheels unpacks the given wheels using the same layout `pip install --target` produces, so that the
+// resulting archive can be used directly as a Lambda deployment package or layer.
+func installPythonWheels(wheelPaths []string, opts PythonWheelOpts) ([]ArchiveEntry, error) {
+	prefix, err := cleanArchivePrefix(opts.TargetPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target prefix: %s", err)
+	}
+	opts.TargetPrefix = prefix
+
+	content := make(map[string][]byte)
+	executables := make(map[string]bool)
+	// Track which wheel installed each path so that conflicts can be reported.
+	owners := make(map[string]string)
+	var conflicts []string
+
+	for _, wheelPath := range wheelPaths {
+		files, err := readPythonWheel(wheelPath, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, file := range files {
+			archivePath := path.Join(opts.TargetPrefix, name)
+
+			if owner, ok := owners[archivePath]; ok {
+				conflicts = append(conflicts, fmt.Sprintf("%s (installed by %s and %s)", archivePath, owner, wheelPath))
+				continue
+			}
+
+			owners[archivePath] = wheelPath
+			content[archivePath] = file.data
+			executables[archivePath] = file.executable
+		}
+	}
+
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return nil, fmt.Errorf("conflicting files in python wheels:\n  %s", strings.Join(conflicts, "\n  "))
+	}
+
+	entries := contentEntries(content)
+	for i := range entries {
+		if executables[entries[i].Name] {
+			entries[i].setMode(0755)
+		}
+	}
+
+	return entries, nil
+}
+
+// wheelFile is a file installed by a wheel. Scripts and the launchers of entry points are executable.
+type wheelFile struct {
+	data       []byte
+	executable bool
+}
+
+// readPythonWheel returns the files of a wheel keyed by their install path relative to the target directory.
+// Every file is verified against the hash recorded in the wheel's RECORD.
+func readPythonWheel(wheelPath string, opts PythonWheelOpts) (map[string]wheelFile, error) {
+	r, err := zip.OpenReader(wheelPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open python wheel %s: %w", wheelPath, err)
+	}
+	defer r.Close()
+
+	distInfo, err := findDistInfo(r.File)
+	if err != nil {
+		return nil, fmt.Errorf("invalid python wheel %s: %w", wheelPath, err)
+	}
+	recordPath := distInfo + "/RECORD"
+	dataDir := strings.TrimSuffix(distInfo, ".dist-info") + ".data"
+	distName := strings.SplitN(distInfo, "-", 2)[0]
+
+	files := make(map[string]wheelFile)
+	installPaths := make(map[string]string)
+	var record []byte
+
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s from python wheel %s: %w", f.Name, wheelPath, err)
+		}
+
+		if f.Name == recordPath {
+			record = data
+			continue
+		}
+
+		installPath, err := wheelInstallPath(f.Name, dataDir, distName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid python wheel %s: %w", wheelPath, err)
+		}
+
+		if _, ok := files[installPath]; ok {
+			return nil, fmt.Errorf("invalid python wheel %s: multiple files install to %s", wheelPath, installPath)
+		}
+
+		files[installPath] = wheelFile{data: data, executable: strings.HasPrefix(f.Name, dataDir+"/scripts/")}
+		installPaths[f.Name] = installPath
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(record)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s in python wheel %s: %w", recordPath, wheelPath, err)
+	}
+
+	for _, row := range rows {
+		if len(row) != 3 {
+			return nil, fmt.Errorf("invalid %s in python wheel %s: expected 3 fields, got %d", recordPath, wheelPath, len(row))
+		}
+
+		installPath, ok := installPaths[row[0]]
+		if !ok || row[1] == "" {
+			continue
+		}
+
+		if err := verifyRecordHash(files[installPath].data, row[1]); err != nil {
+			return nil, fmt.Errorf("could not verify %s in python wheel %s: %w", row[0], wheelPath, err)
+		}
+	}
+
+	launchers, err := wheelEntryPoints(files[distInfo+"/entry_points.txt"].data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entry_points.txt in python wheel %s: %w", wheelPath, err)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(launchers)) {
+		if _, ok := files[name]; ok {
+			return nil, fmt.Errorf("invalid python wheel %s: entry point %s conflicts with a file of the wheel", wheelPath, name)
+		}
+
+		files[name] = wheelFile{data: launchers[name], executable: true}
+	}
+
+	if opts.SkipRecordRewrite {
+		files[recordPath] = wheelFile{data: record}
+		return files, nil
+	}
+
+	// Rewrite RECORD so that entries relocated out of the .data directory point at their installed location, and
+	// record the launchers of entry points as pip does. Paths in RECORD are relative to the target directory, which is
+	// where the .dist-info directory itself is installed.
+	for _, name := range slices.Sorted(maps.Keys(launchers)) {
+		sum := sha256.Sum256(launchers[name])
+		rows = append(rows, []string{name, "sha256=" + base64.RawURLEncoding.EncodeToString(sum[:]), strconv.Itoa(len(launchers[name]))})
+	}
+
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	for _, row := range rows {
+		if installPath, ok := installPaths[row[0]]; ok {
+			row[0] = installPath
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	files[recordPath] = wheelFile{data: buf.Bytes()}
+
+	return files, nil
+}
+
+// wheelEntryPoints returns the launchers which pip generates in bin for the console_scripts and gui_scripts entry
+// points declared by the entry_points.txt of a wheel. Launchers run the python3 found in PATH, rather than the
+// interpreter pip would have been run with, so that archives don't depend on the host which built them.
+func wheelEntryPoints(data []byte) (map[string][]byte, error) {
+	launchers := make(map[string][]byte)
+	var section string
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		if section != "console_scripts" && section != "gui_scripts" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		// Extras, such as in "tool = tool.cli:main [cli]", don't change the launcher.
+		value, _, _ = strings.Cut(value, "[")
+		module, function, hasFunction := strings.Cut(strings.TrimSpace(value), ":")
+		module, function = strings.TrimSpace(module), strings.TrimSpace(function)
+		if !ok || !hasFunction || name == "" || module == "" || function == "" || strings.ContainsAny(name, "/\\") || name == "." || name == ".." {
+			return nil, fmt.Errorf("invalid entry point %q", line)
+		}
+
+		importName, _, _ := strings.Cut(function, ".")
+		launchers[path.Join("bin", name)] = []byte(fmt.Sprintf(wheelLauncher, module, importName, function))
+	}
+
+	return launchers, nil
+}
+
+// wheelLauncher is the script pip generates for an entry point, given its module, the name imported from the module
+// and the function called.
+const wheelLauncher = `#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+import re
+import sys
+from %s import %s
+if __name__ == "__main__":
+    sys.argv[0] = re.sub(r"(-script\.pyw|\.exe)?$", "", sys.argv[0])
+    sys.exit(%s())
+`
+
+// findDistInfo locates the single top-level .dist-info directory which contains the wheel's RECORD.
+func findDistInfo(files []*zip.File) (string, error) {
+	var distInfo string
+
+	for _, f := range files {
+		dir, name := path.Split(f.Name)
+		dir = strings.TrimSuffix(dir, "/")
+		if name != "RECORD" || strings.Contains(dir, "/") || !strings.HasSuffix(dir, ".dist-info") {
+			continue
+		}
+
+		if distInfo != "" {
+			return "", fmt.Errorf("multiple .dist-info directories found: %s, %s", distInfo, dir)
+		}
+		distInfo = dir
+	}
+
+	if distInfo == "" {
+		return "", errors.New("no .dist-info/RECORD found")
+	}
+
+	return distInfo, nil
+}
+
+// wheelInstallPath maps a path inside a wheel to the path it is installed to, relative to the target directory.
+func wheelInstallPath(name, dataDir, distName string) (string, error) {
+	if path.IsAbs(name) || path.Clean(name) != name || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("unsafe path: %s", name)
+	}
+
+	if !strings.HasPrefix(name, dataDir+"/") {
+		return name, nil
+	}
+
+	scheme, rest, _ := strings.Cut(strings.TrimPrefix(name, dataDir+"/"), "/")
+	if rest == "" {
+		return "", fmt.Errorf("unexpected file in %s: %s", dataDir, name)
+	}
+
+	switch scheme {
+	case "purelib", "platlib", "data":
+		return rest, nil
+	case "scripts":
+		return path.Join("bin", rest), nil
+	case "headers":
+		return path.Join("include", distName, rest), nil
+	default:
+		return "", fmt.Errorf("unsupported install scheme %q in %s", scheme, name)
+	}
+}
+
+func verifyRecordHash(data []byte, recordHash string) error {
+	algorithm, want, ok := strings.Cut(recordHash, "=")
+	if !ok {
+		return fmt.Errorf("invalid hash %q", recordHash)
+	}
+
+	var h hash.Hash
+	switch algorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha384":
+		h = sha512.New384()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("unsupported hash algorithm %q", algorithm)
+	}
+	h.Write(data)
+
+	got := base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+	if got != strings.TrimRight(want, "=") {
+		return fmt.Errorf("hash mismatch, got %s=%s, want %s", algorithm, got, recordHash)
+	}
+
+	return nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,237 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPythonWheels_Install(t *testing.T) {
+	wheel := createTestWheel(t, "requests-2.31.0-py3-none-any.whl", "requests-2.31.0", map[string]string{
+		"requests/__init__.py":                         "import requests.api",
+		"requests/api.py":                              "def get(): pass",
+		"requests-2.31.0.data/scripts/requests-cli":    "#!python",
+		"requests-2.31.0.data/purelib/requests_ext.py": "ext = True",
+	})
+
+	entries, err := installPythonWheels([]string{wheel}, PythonWheelOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	content := wheelContent(entries)
+
+	ensureWheelPaths(t, content, []string{
+		"bin/requests-cli",
+		"requests-2.31.0.dist-info/RECORD",
+		"requests-2.31.0.dist-info/WHEEL",
+		"requests/__init__.py",
+		"requests/api.py",
+		"requests_ext.py",
+	})
+
+	if got := string(content["requests_ext.py"]); got != "ext = True" {
+		t.Errorf("mismatched content for requests_ext.py, got %q", got)
+	}
+
+	record := string(content["requests-2.31.0.dist-info/RECORD"])
+	if !strings.Contains(record, "bin/requests-cli,sha256=") || strings.Contains(record, ".data/") {
+		t.Errorf("expected RECORD to be rewritten with installed paths, got:\n%s", record)
+	}
+}
+
+func TestPythonWheels_TargetPrefix(t *testing.T) {
+	wheel := createTestWheel(t, "six-1.16.0-py2.py3-none-any.whl", "six-1.16.0", map[string]string{
+		"six.py": "# six",
+	})
+
+	entries, err := installPythonWheels([]string{wheel}, PythonWheelOpts{TargetPrefix: "python"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureWheelPaths(t, wheelContent(entries), []string{
+		"python/six-1.16.0.dist-info/RECORD",
+		"python/six-1.16.0.dist-info/WHEEL",
+		"python/six.py",
+	})
+}
+
+func TestPythonWheels_SkipRecordRewrite(t *testing.T) {
+	wheel := createTestWheel(t, "tool-1.0-py3-none-any.whl", "tool-1.0", map[string]string{
+		"tool-1.0.data/scripts/tool": "#!python",
+	})
+
+	entries, err := installPythonWheels([]string{wheel}, PythonWheelOpts{SkipRecordRewrite: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	record := string(wheelContent(entries)["tool-1.0.dist-info/RECORD"])
+	if !strings.Contains(record, "tool-1.0.data/scripts/tool,sha256=") {
+		t.Errorf("expected RECORD to be archived as-is, got:\n%s", record)
+	}
+}
+
+func TestPythonWheels_HashMismatch(t *testing.T) {
+	wheel := filepath.Join(t.TempDir(), "bad-1.0-py3-none-any.whl")
+	writeTestWheel(t, wheel, map[string]string{
+		"bad.py":                   "tampered",
+		"bad-1.0.dist-info/RECORD": "bad.py,sha256=" + recordHash("original") + ",8\nbad-1.0.dist-info/RECORD,,\n",
+	})
+
+	_, err := installPythonWheels([]string{wheel}, PythonWheelOpts{})
+	if err == nil || !strings.Contains(err.Error(), "hash mismatch") {
+		t.Fatalf("expected hash mismatch error, got: %v", err)
+	}
+}
+
+func TestPythonWheels_Conflict(t *testing.T) {
+	first := createTestWheel(t, "first-1.0-py3-none-any.whl", "first-1.0", map[string]string{
+		"shared/__init__.py": "first",
+	})
+	second := createTestWheel(t, "second-1.0-py3-none-any.whl", "second-1.0", map[string]string{
+		"shared/__init__.py": "second",
+	})
+
+	_, err := installPythonWheels([]string{first, second}, PythonWheelOpts{})
+	if err == nil {
+		t.Fatal("expected conflict error")
+	}
+	if !strings.Contains(err.Error(), fmt.Sprintf("shared/__init__.py (installed by %s and %s)", first, second)) {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestPythonWheels_Executables(t *testing.T) {
+	wheel := createTestWheel(t, "tool-1.0-py3-none-any.whl", "tool-1.0", map[string]string{
+		"tool/__init__.py":              "",
+		"tool/cli.py":                   "def main(): pass",
+		"tool-1.0.data/scripts/tool-sh": "#!/bin/sh",
+		"tool-1.0.dist-info/entry_points.txt": "[console_scripts]\ntool = tool.cli:main [cli]\n\n" +
+			"[tool.plugins]\nignored = tool.plugins:load\n",
+	})
+
+	entries, err := installPythonWheels([]string{wheel}, PythonWheelOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	modes := make(map[string]os.FileMode)
+	for _, entry := range entries {
+		modes[entry.Name] = entry.Mode
+	}
+	wants := map[string]os.FileMode{
+		"bin/tool":         0755,
+		"bin/tool-sh":      0755,
+		"tool/__init__.py": 0,
+		"tool/cli.py":      0,
+	}
+	for name, want := range wants {
+		if got := modes[name]; got != want {
+			t.Errorf("mismatched mode for %s, got %s, want %s", name, got, want)
+		}
+	}
+
+	content := wheelContent(entries)
+	if launcher := string(content["bin/tool"]); !strings.HasPrefix(launcher, "#!/usr/bin/env python3\n") ||
+		!strings.Contains(launcher, "from tool.cli import main\n") || !strings.Contains(launcher, "sys.exit(main())") {
+		t.Errorf("unexpected launcher for bin/tool:\n%s", launcher)
+	}
+
+	record := string(content["tool-1.0.dist-info/RECORD"])
+	if !strings.Contains(record, "bin/tool,sha256="+recordHash(string(content["bin/tool"]))) {
+		t.Errorf("expected RECORD to record the entry point launcher, got:\n%s", record)
+	}
+}
+
+// createTestWheel writes a wheel containing files along with a WHEEL and RECORD file in the distInfo directory.
+func createTestWheel(t *testing.T, filename, distInfo string, files map[string]string) string {
+	t.Helper()
+
+	all := map[string]string{
+		distInfo + ".dist-info/WHEEL": "Wheel-Version: 1.0\nRoot-Is-Purelib: true\n",
+	}
+	for name, content := range files {
+		all[name] = content
+	}
+
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	record := &strings.Builder{}
+	for _, name := range names {
+		fmt.Fprintf(record, "%s,sha256=%s,%d\n", name, recordHash(all[name]), len(all[name]))
+	}
+	fmt.Fprintf(record, "%s.dist-info/RECORD,,\n", distInfo)
+	all[distInfo+".dist-info/RECORD"] = record.String()
+
+	wheel := filepath.Join(t.TempDir(), filename)
+	writeTestWheel(t, wheel, all)
+
+	return wheel
+}
+
+func writeTestWheel(t *testing.T, wheel string, files map[string]string) {
+	t.Helper()
+
+	f, err := os.Create(wheel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func recordHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func wheelContent(entries []ArchiveEntry) map[string][]byte {
+	content := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		content[entry.Name] = entry.Content
+	}
+
+	return content
+}
+
+func ensureWheelPaths(t *testing.T, content map[string][]byte, wants []string) {
+	t.Helper()
+
+	got := make([]string, 0, len(content))
+	for name := range content {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+
+	if strings.Join(got, ",") != strings.Join(wants, ",") {
+		t.Errorf("mismatched installed files\ngot\n%s\nwant\n%s", got, wants)
+	}
+}
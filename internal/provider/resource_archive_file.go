@@ -9,6 +9,9 @@ import (
 	"os"
 	"path"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/boolvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
@@ -16,14 +19,22 @@ import (
 	fwpath "github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/setplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
-var _ resource.Resource = (*archiveFileResource)(nil)
+var (
+	_ resource.Resource               = (*archiveFileResource)(nil)
+	_ resource.ResourceWithModifyPlan = (*archiveFileResource)(nil)
+)
 
 func NewArchiveFileResource() resource.Resource {
 	return &archiveFileResource{}
@@ -38,23 +49,52 @@ func (d *archiveFileResource) ConfigValidators(context.Context) []resource.Confi
 			fwpath.MatchRoot("source_content_filename"),
 			fwpath.MatchRoot("source_file"),
 			fwpath.MatchRoot("source_dir"),
+			fwpath.MatchRoot("python_wheels"),
+			fwpath.MatchRoot("source_directory"),
+			fwpath.MatchRoot("source_git"),
+			fwpath.MatchRoot("source_archive"),
+			fwpath.MatchRoot("source_url"),
+			fwpath.MatchRoot("entry"),
 		),
 	}
 }
 
+func (d *archiveFileResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	resp.Diagnostics.Append(validateArchivePaths(ctx, req.Config)...)
+	resp.Diagnostics.Append(validateArchiveType(ctx, req.Config)...)
+}
+
 func (d *archiveFileResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Generates an archive from content, a file, or directory of files.",
 		Blocks: map[string]schema.Block{
 			"source": schema.SetNestedBlock{
 				Description: "Specifies attributes of a single source file to include into the archive. " +
-					"One and only one of `source`, `source_content_filename` (with `source_content`), `source_file`, " +
-					"or `source_dir` must be specified.",
+					"Only one of `source`, `source_content_filename` (with `source_content`), `source_file` or " +
+					"`source_dir` can be specified. The other sources, such as `source_directory` or `entry`, can be " +
+					"combined with it and with each other.",
 				NestedObject: schema.NestedBlockObject{
 					Attributes: map[string]schema.Attribute{
 						"content": schema.StringAttribute{
-							Description: "Add this content to the archive with `filename` as the filename.",
-							Required:    true,
+							Description: "Add this content to the archive with `filename` as the filename. " +
+								"Exactly one of `content` or `content_base64` must be specified.",
+							Optional: true,
+							Validators: []validator.String{
+								stringvalidator.ExactlyOneOf(
+									fwpath.MatchRelative().AtParent().AtName("content_base64"),
+								),
+							},
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"content_base64": schema.StringAttribute{
+							Description: "Add this base64-encoded binary content to the archive with `filename` as " +
+								"the filename.",
+							Optional: true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(base64Regexp, "must be base64-encoded"),
+							},
 							PlanModifiers: []planmodifier.String{
 								stringplanmodifier.RequiresReplace(),
 							},
@@ -77,6 +117,515 @@ func (d *archiveFileResource) Schema(ctx context.Context, req resource.SchemaReq
 					),
 				},
 			},
+			"python_wheels": schema.ListNestedBlock{
+				Description: "Installs local Python wheel (`.whl`) files into the archive using the same layout as " +
+					"`pip install --target`: scripts and the launchers generated for `console_scripts` and `gui_scripts` " +
+					"entry points are installed executable in `bin`. Can be combined with any other source.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"paths": schema.ListAttribute{
+							Description: "Paths of the wheel files to install.",
+							ElementType: types.StringType,
+							Required:    true,
+							PlanModifiers: []planmodifier.List{
+								listplanmodifier.RequiresReplace(),
+							},
+						},
+						"target_prefix": schema.StringAttribute{
+							Description: "Directory inside the archive to install the wheels into, for example `python` " +
+								"for a Lambda layer. Defaults to the root of the archive.",
+							Optional: true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"skip_record_rewrite": schema.BoolAttribute{
+							Description: "Boolean flag indicating whether each wheel's `*.dist-info/RECORD` should be " +
+								"archived as-is instead of being rewritten with the installed paths of relocated files. " +
+								"Defaults to `false`.",
+							Optional: true,
+							PlanModifiers: []planmodifier.Bool{
+								boolplanmodifier.RequiresReplace(),
+							},
+						},
+					},
+				},
+			},
+			"source_directory": schema.ListNestedBlock{
+				Description: "Package the contents of a directory into the archive. Can be repeated to combine several " +
+					"directories into one archive, in which case the files of all directories are archived in sorted " +
+					"order and a file which would be archived under the same path from more than one directory is an error.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"path": schema.StringAttribute{
+							Description: "Path of the directory to package.",
+							Required:    true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"target_prefix": schema.StringAttribute{
+							Description: "Directory inside the archive to place the files of this directory into. " +
+								"Defaults to the root of the archive.",
+							Optional: true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"strip_components": schema.Int64Attribute{
+							Description: "Number of leading directories to remove from the paths of the files of this " +
+								"directory, like `tar --strip-components` does. Files with fewer directories are ignored. " +
+								"Defaults to `0`.",
+							Optional: true,
+							Validators: []validator.Int64{
+								int64validator.AtLeast(0),
+							},
+							PlanModifiers: []planmodifier.Int64{
+								int64planmodifier.RequiresReplace(),
+							},
+						},
+						"flatten": schema.BoolAttribute{
+							Description: "Boolean flag indicating whether the files of this directory should be archived " +
+								"by their base name, at the root of the archive or of `target_prefix`, dropping their " +
+								"directories. Files archived under the same name are resolved by `on_collision`. " +
+								"Defaults to `false`.",
+							Optional: true,
+							PlanModifiers: []planmodifier.Bool{
+								boolplanmodifier.RequiresReplace(),
+							},
+						},
+						"includes": schema.SetAttribute{
+							Description: "Specify files/directories to package when reading this directory, relative to " +
+								"`path`, in which case only files matching one of the patterns and none of the `excludes` " +
+								"are archived. Supports glob file matching patterns including doublestar/globstar (`**`) patterns.",
+							ElementType: types.StringType,
+							Optional:    true,
+							PlanModifiers: []planmodifier.Set{
+								setplanmodifier.RequiresReplace(),
+							},
+						},
+						"excludes": schema.ListAttribute{
+							Description: "Specify files/directories to ignore when reading this directory, relative to `path`. " +
+								"Supports glob file matching patterns including doublestar/globstar (`**`) patterns, or " +
+								"`.gitignore` patterns when `excludes_syntax` is `gitignore`, in which case their order " +
+								"matters.",
+							ElementType: types.StringType,
+							Optional:    true,
+							PlanModifiers: []planmodifier.List{
+								excludesOrderModifier{},
+								listplanmodifier.RequiresReplace(),
+							},
+						},
+						"excludes_syntax": schema.StringAttribute{
+							Description: "The syntax of `excludes`, either `glob` (default) or `gitignore`. See the " +
+								"top-level `excludes_syntax` for details.",
+							Optional: true,
+							Validators: []validator.String{
+								stringvalidator.OneOf(ExcludesSyntaxGlob, ExcludesSyntaxGitignore),
+							},
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"ignore_files": schema.ListAttribute{
+							Description: "Names of ignore files, such as `.gitignore`, to read from this directory and " +
+								"each directory below it. See the top-level `ignore_files` for details.",
+							ElementType: types.StringType,
+							Optional:    true,
+							PlanModifiers: []planmodifier.List{
+								listplanmodifier.RequiresReplace(),
+							},
+						},
+						"exclude_larger_than": schema.Int64Attribute{
+							Description: "Exclude files of this directory larger than this many bytes.",
+							Optional:    true,
+							Validators: []validator.Int64{
+								int64validator.AtLeast(1),
+							},
+							PlanModifiers: []planmodifier.Int64{
+								int64planmodifier.RequiresReplace(),
+							},
+						},
+						"exclude_older_than": schema.StringAttribute{
+							Description: "Exclude files of this directory last modified before this time, either an RFC3339 " +
+								"timestamp or a duration before the time the archive is created, such as `720h`.",
+							Optional: true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"exclude_presets": schema.SetAttribute{
+							Description: "Curated sets of excludes for common ecosystems, applied to this directory before " +
+								"`excludes`: `vcs`, `python`, `node`, `terraform` or `os_junk`. See the top-level " +
+								"`exclude_presets` for the patterns of each preset.",
+							ElementType: types.StringType,
+							Optional:    true,
+							Validators: []validator.Set{
+								setvalidator.ValueStringsAre(
+									stringvalidator.OneOf(excludePresetNames...),
+								),
+							},
+							PlanModifiers: []planmodifier.Set{
+								setplanmodifier.RequiresReplace(),
+							},
+						},
+						"exclude_file_types": schema.SetAttribute{
+							Description: "Exclude files of this directory by type: `socket`, `fifo`, `device` (character and block " +
+								"devices) or `empty` (regular files without content).",
+							ElementType: types.StringType,
+							Optional:    true,
+							Validators: []validator.Set{
+								setvalidator.ValueStringsAre(
+									stringvalidator.OneOf(FileTypeSocket, FileTypeFIFO, FileTypeDevice, FileTypeEmpty),
+								),
+							},
+							PlanModifiers: []planmodifier.Set{
+								setplanmodifier.RequiresReplace(),
+							},
+						},
+						"exclude_symlink_directories": schema.BoolAttribute{
+							Description: "Boolean flag indicating whether symbolically linked directories should be " +
+								"excluded when reading this directory. Defaults to `false`.",
+							Optional: true,
+							PlanModifiers: []planmodifier.Bool{
+								boolplanmodifier.RequiresReplace(),
+							},
+						},
+						"symlink_mode": schema.StringAttribute{
+							Description: "How to archive the symbolic links of this directory: `follow`, `preserve` " +
+								"or `skip`, like the top-level `symlink_mode`. Defaults to `follow`.",
+							Optional: true,
+							Validators: []validator.String{
+								stringvalidator.OneOf(SymlinkModeFollow, SymlinkModePreserve, SymlinkModeSkip),
+							},
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+					},
+				},
+			},
+			"source_git": schema.ListNestedBlock{
+				Description: "Package the files committed to a local git repository, like `git archive` does. The files " +
+					"are read from the object database of the repository, so untracked and modified files in the " +
+					"worktree are ignored, and are archived with the modes recorded in git. Symbolic links are " +
+					"archived as such whatever `symlink_mode`, and must point inside the archive. The SHA of the " +
+					"commit is exported as `source_git_commit`.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"repository_path": schema.StringAttribute{
+							Description: "Path of the git repository, either a worktree containing a `.git` directory or " +
+								"a bare repository.",
+							Required: true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"ref": schema.StringAttribute{
+							Description: "The branch, tag or full commit SHA to archive, resolved like `git rev-parse` " +
+								"does. Defaults to `HEAD`.",
+							Optional: true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"subdirectory": schema.StringAttribute{
+							Description: "Directory of the repository to archive, in which case the files are archived " +
+								"relative to it. Defaults to the root of the repository.",
+							Optional: true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+					},
+				},
+				Validators: []validator.List{
+					listvalidator.SizeAtMost(1),
+				},
+			},
+			"source_archive": schema.ListNestedBlock{
+				Description: "Package the files of an existing zip or tar archive, optionally compressed with gzip or " +
+					"bzip2, without extracting it, for example to convert a zip to a tar.gz or to drop some of its " +
+					"files. Files keep the modes recorded in the archive, and symbolic links are archived as such " +
+					"whatever `symlink_mode`, and must point inside the new archive once `strip_components` and " +
+					"`target_prefix` are applied. Can be repeated, in which case a file which would be archived under " +
+					"the same path from more than one archive is an error.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"path": schema.StringAttribute{
+							Description: "Path of the archive to read.",
+							Required:    true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"excludes": schema.SetAttribute{
+							Description: "Specify files/directories of the archive to ignore, relative to the root of " +
+								"the archive after `strip_components` is applied. Supports glob file matching patterns " +
+								"including doublestar/globstar (`**`) patterns.",
+							ElementType: types.StringType,
+							Optional:    true,
+							PlanModifiers: []planmodifier.Set{
+								setplanmodifier.RequiresReplace(),
+							},
+						},
+						"strip_components": schema.Int64Attribute{
+							Description: "Number of leading directories to remove from the paths of the files of the " +
+								"archive, like `tar --strip-components` does. Files with fewer directories are ignored. " +
+								"Defaults to `0`.",
+							Optional: true,
+							Validators: []validator.Int64{
+								int64validator.AtLeast(0),
+							},
+							PlanModifiers: []planmodifier.Int64{
+								int64planmodifier.RequiresReplace(),
+							},
+						},
+						"target_prefix": schema.StringAttribute{
+							Description: "Directory inside the archive to place the files of this archive into. " +
+								"Defaults to the root of the archive.",
+							Optional: true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+					},
+				},
+			},
+			"source_url": schema.ListNestedBlock{
+				Description: "Download a file over HTTP(S) into the archive. The file is cached by checksum, in the " +
+					"directory set by the `TF_ARCHIVE_CACHE_DIR` environment variable or else in the user cache " +
+					"directory, and is not downloaded again while the cached file matches `sha256`. Downloads time out " +
+					"after 10 minutes. Can be repeated.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"url": schema.StringAttribute{
+							Description: "The `http` or `https` URL of the file.",
+							Required:    true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"sha256": schema.StringAttribute{
+							Description: "The hex-encoded SHA256 checksum the downloaded file must match before it is " +
+								"archived.",
+							Required: true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(sha256Regexp, "must be a hex-encoded SHA256 checksum"),
+							},
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"target": schema.StringAttribute{
+							Description: "Path of the file inside the archive.",
+							Required:    true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"mode": schema.StringAttribute{
+							Description: "String that specifies the octal file mode of the downloaded file, for example " +
+								"`\"0755\"`. Takes precedence over `output_file_mode` and `file_mode_rule` blocks. " +
+								"Downloaded files are otherwise archived with the mode `0644`, unless a `file_mode_rule` " +
+								"block or `auto_executable` sets it.",
+							Optional: true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(fileModeRegexp,
+									"must be an octal file mode such as \"0644\""),
+							},
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"headers": schema.MapAttribute{
+							Description: "HTTP headers to send with the request, for example for authentication.",
+							ElementType: types.StringType,
+							Optional:    true,
+							Sensitive:   true,
+							PlanModifiers: []planmodifier.Map{
+								mapplanmodifier.RequiresReplace(),
+							},
+						},
+					},
+				},
+			},
+			"entry": schema.ListNestedBlock{
+				Description: "Adds a file, a directory or inline content to the archive. Can be repeated and combined " +
+					"with any other source, and entries are archived in the order they are declared. Exactly one of " +
+					"`content`, `content_base64`, `file` or `directory` must be specified.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"content": schema.StringAttribute{
+							Description: "Add this content to the archive with `target` as the filename.",
+							Optional:    true,
+							Validators: []validator.String{
+								stringvalidator.ExactlyOneOf(
+									fwpath.MatchRelative().AtParent().AtName("content_base64"),
+									fwpath.MatchRelative().AtParent().AtName("file"),
+									fwpath.MatchRelative().AtParent().AtName("directory"),
+								),
+								stringvalidator.AlsoRequires(fwpath.MatchRelative().AtParent().AtName("target")),
+							},
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"content_base64": schema.StringAttribute{
+							Description: "Add this base64-encoded binary content to the archive with `target` as the filename.",
+							Optional:    true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(base64Regexp, "must be base64-encoded"),
+								stringvalidator.AlsoRequires(fwpath.MatchRelative().AtParent().AtName("target")),
+							},
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"file": schema.StringAttribute{
+							Description: "Package this file into the archive, with `target` as the filename if set or " +
+								"the base name of the file otherwise.",
+							Optional: true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"directory": schema.StringAttribute{
+							Description: "Package entire contents of this directory into the archive, below `target` " +
+								"if set or at the root of the archive otherwise.",
+							Optional: true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"target": schema.StringAttribute{
+							Description: "The path of the entry inside the archive. Required for `content` and " +
+								"`content_base64`.",
+							Optional: true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"mode": schema.StringAttribute{
+							Description: "String that specifies the octal file mode of the archived files of this entry, " +
+								"for example `\"0755\"`. Takes precedence over `output_file_mode`.",
+							Optional: true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(fileModeRegexp,
+									"must be an octal file mode such as \"0644\""),
+							},
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"mtime": schema.StringAttribute{
+							Description: "RFC3339 timestamp to record as the modification time of the archived files " +
+								"of this entry, for example `\"2024-01-01T00:00:00Z\"`.",
+							Optional: true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+					},
+				},
+			},
+			"file_mode_rule": schema.ListNestedBlock{
+				Description: "Set the mode of the archived files matching `pattern`, taking precedence over " +
+					"`auto_executable` and `output_file_mode`. Rules are evaluated in order and the first rule matching " +
+					"a file sets its mode. Files of `entry` blocks with a `mode` keep it.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"pattern": schema.StringAttribute{
+							Description: "Glob pattern matched against the path of each file inside the archive, " +
+								"including doublestar/globstar (`**`) patterns, for example `bin/**` or `**/*.sh`.",
+							Required: true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"mode": schema.StringAttribute{
+							Description: "String that specifies the octal file mode of the matching files, for example " +
+								"`\"0755\"`.",
+							Required: true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(fileModeRegexp, "must be an octal file mode such as \"0644\""),
+							},
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+					},
+				},
+			},
+			"owner_rule": schema.ListNestedBlock{
+				Description: "Set the owner and the group of the files of tar archives matching `pattern`, taking " +
+					"precedence over `owner` and `group`. Rules are evaluated in order and the first rule matching a " +
+					"file sets its owner and group. Not supported by zip archives.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"pattern": schema.StringAttribute{
+							Description: "Glob pattern matched against the path of each file inside the archive, " +
+								"including doublestar/globstar (`**`) patterns, for example `bin/**` or `**/*.sh`.",
+							Required: true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"owner": schema.StringAttribute{
+							Description: "The owner of the matching files, given like `owner`. Defaults to `owner`.",
+							Optional:    true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(ownerRegexp, "must be an ID, a name or a name:ID pair"),
+							},
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"group": schema.StringAttribute{
+							Description: "The group of the matching files, given like `group`. Defaults to `group`.",
+							Optional:    true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(ownerRegexp, "must be an ID, a name or a name:ID pair"),
+							},
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+					},
+				},
+			},
+			"path_transform": schema.ListNestedBlock{
+				Description: "Rename files on their way into the archive, like `tar --transform` does. Transforms are " +
+					"applied in order to the slash separated path of each file of `source_dir` and `source_directory`, " +
+					"relative to its directory, and to the filenames of `source`, each to the result of the previous " +
+					"one. Two files renamed to the same path are an error.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"match": schema.StringAttribute{
+							Description: "Regular expression, in RE2 syntax, matched against the path of each file.",
+							Required:    true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+						"replace": schema.StringAttribute{
+							Description: "Replacement of every match of `match`, in which `$1` or `${name}` refer to " +
+								"its submatches. An empty string removes the matches.",
+							Required: true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.RequiresReplace(),
+							},
+						},
+					},
+				},
+				Validators: []validator.List{
+					listvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+			},
 		},
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
@@ -92,14 +641,32 @@ func (d *archiveFileResource) Schema(ctx context.Context, req resource.SchemaReq
 			},
 			"source_content": schema.StringAttribute{
 				Description: "Add only this content to the archive with `source_content_filename` as the filename. " +
-					"One and only one of `source`, `source_content_filename` (with `source_content`), `source_file`, " +
-					"or `source_dir` must be specified.",
+					"Only one of `source`, `source_content_filename` (with `source_content`), `source_file` or " +
+					"`source_dir` can be specified. The other sources, such as `source_directory` or `entry`, can be " +
+					"combined with it and with each other.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_dir"),
+					),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"source_content_base64": schema.StringAttribute{
+				Description: "Add only this base64-encoded binary content to the archive with " +
+					"`source_content_filename` as the filename.",
 				Optional: true,
 				Validators: []validator.String{
+					stringvalidator.RegexMatches(base64Regexp, "must be base64-encoded"),
 					stringvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_content"),
 						fwpath.MatchRoot("source_file"),
 						fwpath.MatchRoot("source_dir"),
 					),
+					stringvalidator.AlsoRequires(fwpath.MatchRoot("source_content_filename")),
 				},
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.RequiresReplace(),
@@ -107,8 +674,9 @@ func (d *archiveFileResource) Schema(ctx context.Context, req resource.SchemaReq
 			},
 			"source_content_filename": schema.StringAttribute{
 				Description: "Set this as the filename when using `source_content`. " +
-					"One and only one of `source`, `source_content_filename` (with `source_content`), `source_file`, " +
-					"or `source_dir` must be specified.",
+					"Only one of `source`, `source_content_filename` (with `source_content`), `source_file` or " +
+					"`source_dir` can be specified. The other sources, such as `source_directory` or `entry`, can be " +
+					"combined with it and with each other.",
 				Optional: true,
 				Validators: []validator.String{
 					stringvalidator.ConflictsWith(
@@ -122,8 +690,9 @@ func (d *archiveFileResource) Schema(ctx context.Context, req resource.SchemaReq
 			},
 			"source_file": schema.StringAttribute{
 				Description: "Package this file into the archive. " +
-					"One and only one of `source`, `source_content_filename` (with `source_content`), `source_file`, " +
-					"or `source_dir` must be specified.",
+					"Only one of `source`, `source_content_filename` (with `source_content`), `source_file` or " +
+					"`source_dir` can be specified. The other sources, such as `source_directory` or `entry`, can be " +
+					"combined with it and with each other.",
 				Optional: true,
 				Validators: []validator.String{
 					stringvalidator.ConflictsWith(
@@ -136,10 +705,22 @@ func (d *archiveFileResource) Schema(ctx context.Context, req resource.SchemaReq
 					stringplanmodifier.RequiresReplace(),
 				},
 			},
+			"source_file_target": schema.StringAttribute{
+				Description: "Path inside the archive to store `source_file` as, for example `bin/app`. Defaults to " +
+					"the name of `source_file`.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(fwpath.MatchRoot("source_file")),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
 			"source_dir": schema.StringAttribute{
 				Description: "Package entire contents of this directory into the archive. " +
-					"One and only one of `source`, `source_content_filename` (with `source_content`), `source_file`, " +
-					"or `source_dir` must be specified.",
+					"Only one of `source`, `source_content_filename` (with `source_content`), `source_file` or " +
+					"`source_dir` can be specified. The other sources, such as `source_directory` or `entry`, can be " +
+					"combined with it and with each other.",
 				Optional: true,
 				Validators: []validator.String{
 					stringvalidator.ConflictsWith(
@@ -152,8 +733,71 @@ func (d *archiveFileResource) Schema(ctx context.Context, req resource.SchemaReq
 					stringplanmodifier.RequiresReplace(),
 				},
 			},
-			"excludes": schema.SetAttribute{
-				Description: "Specify files/directories to ignore when reading the `source_dir`. " +
+			"target_prefix": schema.StringAttribute{
+				Description: "Directory inside the archive to place the files of `source_dir` into, for example " +
+					"`myapp-1.2.3` for a release archive which unpacks into its own directory. Defaults to the root " +
+					"of the archive.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"strip_components": schema.Int64Attribute{
+				Description: "Number of leading directories to remove from the paths of the files of `source_dir`, " +
+					"like `tar --strip-components` does. Files with fewer directories are ignored. Defaults to `0`.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+					int64validator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"flatten": schema.BoolAttribute{
+				Description: "Boolean flag indicating whether the files of `source_dir` should be archived by their " +
+					"base name, at the root of the archive or of `target_prefix`, dropping their directories. Files " +
+					"archived under the same name are resolved by `on_collision`. Defaults to `false`.",
+				Optional: true,
+				Validators: []validator.Bool{
+					boolvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"on_collision": schema.StringAttribute{
+				Description: "What to do when `flatten`, `strip_components` or `path_transform` would archive several " +
+					"files of the same directory, or of `source`, under the same name: `error` (default) lists the " +
+					"conflicting files, `first` keeps the file walked first and `last` the file walked last, in " +
+					"lexical order.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(OnCollisionError, OnCollisionFirst, OnCollisionLast),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"includes": schema.SetAttribute{
+				Description: "Specify files/directories to package when reading the `source_dir`, in which case only " +
+					"files matching one of the patterns and none of the `excludes` are archived. A pattern matching a " +
+					"directory includes everything below it. " +
 					"Supports glob file matching patterns including doublestar/globstar (`**`) patterns.",
 				ElementType: types.StringType,
 				Optional:    true,
@@ -168,11 +812,193 @@ func (d *archiveFileResource) Schema(ctx context.Context, req resource.SchemaReq
 					setplanmodifier.RequiresReplace(),
 				},
 			},
+			"excludes": schema.ListAttribute{
+				Description: "Specify files/directories to ignore when reading the `source_dir`. " +
+					"Supports glob file matching patterns including doublestar/globstar (`**`) patterns, or " +
+					"`.gitignore` patterns when `excludes_syntax` is `gitignore`, in which case their order matters.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.List{
+					listvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+				PlanModifiers: []planmodifier.List{
+					excludesOrderModifier{},
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"excludes_syntax": schema.StringAttribute{
+				Description: "The syntax of `excludes`. With `glob` (default), a file is excluded when it matches any " +
+					"of the patterns. With `gitignore`, the patterns follow the `.gitignore` format and are evaluated " +
+					"in order, with the last matching pattern taking precedence: a `!` prefix re-includes files " +
+					"excluded by an earlier pattern, a trailing `/` only matches directories, and patterns without a " +
+					"`/` match at any level while other patterns are relative to `source_dir`. As with git, a file " +
+					"cannot be re-included if one of its parent directories is excluded, except for the directories " +
+					"inside `dir` excluded by a `dir/**` pattern, which only matches the paths inside `dir`: " +
+					"`node_modules/**` followed by `!node_modules/.bin/mytool` archives `mytool` alone.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(ExcludesSyntaxGlob, ExcludesSyntaxGitignore),
+					stringvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"ignore_files": schema.ListAttribute{
+				Description: "Names of ignore files, for example `[\".gitignore\", \".archiveignore\"]`, to read from " +
+					"`source_dir` and each directory below it. The patterns of an ignore file follow the `.gitignore` " +
+					"format and apply to the files below its directory, with the patterns of deeper ignore files, and " +
+					"of later names in the list, taking precedence. Files excluded by `excludes` cannot be re-included " +
+					"by an ignore file.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.List{
+					listvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"exclude_larger_than": schema.Int64Attribute{
+				Description: "Exclude files of `source_dir` larger than this many bytes.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+					int64validator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"exclude_older_than": schema.StringAttribute{
+				Description: "Exclude files of `source_dir` last modified before this time, either an RFC3339 " +
+					"timestamp or a duration before the time the archive is created, such as `720h`.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"exclude_presets": schema.SetAttribute{
+				Description: "Curated sets of excludes for common ecosystems, applied to `source_dir` before `excludes`. " +
+					"With the `gitignore` syntax, `excludes` can re-include files excluded by a preset. The patterns " +
+					"match files or directories at any level, and none of the files of a matching directory are " +
+					"archived: " + excludePresetsDescription() + ".",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Set{
+					setvalidator.ValueStringsAre(
+						stringvalidator.OneOf(excludePresetNames...),
+					),
+					setvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+				PlanModifiers: []planmodifier.Set{
+					setplanmodifier.RequiresReplace(),
+				},
+			},
+			"exclude_file_types": schema.SetAttribute{
+				Description: "Exclude files of `source_dir` by type: `socket`, `fifo`, `device` (character and block " +
+					"devices) or `empty` (regular files without content).",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Set{
+					setvalidator.ValueStringsAre(
+						stringvalidator.OneOf(FileTypeSocket, FileTypeFIFO, FileTypeDevice, FileTypeEmpty),
+					),
+					setvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+				PlanModifiers: []planmodifier.Set{
+					setplanmodifier.RequiresReplace(),
+				},
+			},
 			"exclude_symlink_directories": schema.BoolAttribute{
 				Optional: true,
 				Description: "Boolean flag indicating whether symbolically linked directories should be excluded during " +
 					"the creation of the archive. Defaults to `false`.",
 			},
+			"symlink_mode": schema.StringAttribute{
+				Description: "How to archive the symbolic links of `source_dir`. `follow` archives the files they point " +
+					"to, and the content of the directories they point to unless `exclude_symlink_directories` is set, " +
+					"`preserve` archives them as symbolic links, which must point inside `source_dir`, and `skip` " +
+					"leaves them out. Defaults to `follow`.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(SymlinkModeFollow, SymlinkModePreserve, SymlinkModeSkip),
+					stringvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"include_directories": schema.BoolAttribute{
+				Description: "Boolean flag indicating whether the directories of `source_dir` and `source_directory` " +
+					"blocks, including empty ones, should be archived as directory entries, with the mode `0755` " +
+					"unless `directory_mode` is set. With `includes`, only the directories matching them are " +
+					"archived. Defaults to `false`.",
+				Optional: true,
+				Validators: []validator.Bool{
+					boolvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"node_prune_dev_dependencies": schema.BoolAttribute{
+				Description: "Boolean flag indicating whether only production dependencies should be included from " +
+					"`node_modules` when reading the `source_dir`. The dependency graph is read from the " +
+					"`package-lock.json` (`lockfileVersion` 2 or 3) at the root of `source_dir`, and files below " +
+					"`node_modules` which do not belong to a production package are excluded, except the links of " +
+					"`node_modules/.bin` to the executables of production packages. Defaults to `false`.",
+				Optional: true,
+				Validators: []validator.Bool{
+					boolvalidator.ConflictsWith(
+						fwpath.MatchRoot("source_file"),
+						fwpath.MatchRoot("source_content"),
+						fwpath.MatchRoot("source_content_filename"),
+					),
+				},
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
 			"output_path": schema.StringAttribute{
 				Description: "The output of the archive file.",
 				Required:    true,
@@ -181,7 +1007,42 @@ func (d *archiveFileResource) Schema(ctx context.Context, req resource.SchemaReq
 				},
 			},
 			"output_size": schema.Int64Attribute{
-				Description: "The byte size of the output archive file.",
+				Description: "The byte size of the output archive file. When `split_size` is set, this is the size of " +
+					"the archive before it is split.",
+				Computed: true,
+			},
+			"split_size": schema.Int64Attribute{
+				Description: "Split the output into volumes of at most this many bytes, which must be at least 65536. " +
+					"`zip` archives are written as a standard split archive, where all but the last volume are named " +
+					"with a `.z01`, `.z02`, ... extension and the last volume is written to `output_path`. Other " +
+					"archive types are written to `output_path.001`, `output_path.002`, ... instead of `output_path`. " +
+					"The `output_*` checksums are those of the archive before it is split, which are the same as " +
+					"those of the archive written without `split_size`, and the checksum of each volume is available " +
+					"in `output_parts`. The volumes of `tar.gz` archives concatenated yield the archive, while those " +
+					"of `zip` archives record offsets relative to each volume and must be reassembled, for example " +
+					"with `zip -s 0`.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(minSplitSize),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"output_parts": schema.ListAttribute{
+				Description: "The volumes written when `split_size` is set, in order. Each volume has a `path`, " +
+					"a `size` in bytes and a `sha256` checksum.",
+				ElementType: types.ObjectType{AttrTypes: outputPartAttrTypes},
+				Computed:    true,
+			},
+			"ignore_files_used": schema.ListAttribute{
+				Description: "The paths of the ignore files read when `ignore_files` is set, in the order they " +
+					"were read.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"source_git_commit": schema.StringAttribute{
+				Description: "The SHA of the commit archived by `source_git`.",
 				Computed:    true,
 			},
 			"output_file_mode": schema.StringAttribute{
@@ -193,6 +1054,124 @@ func (d *archiveFileResource) Schema(ctx context.Context, req resource.SchemaReq
 					stringplanmodifier.RequiresReplace(),
 				},
 			},
+			"directory_mode": schema.StringAttribute{
+				Description: "String that specifies the octal file mode of the directory entries of the archive, " +
+					"written when `include_directories` is set, for example `\"0755\"`.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(fileModeRegexp, "must be an octal file mode such as \"0755\""),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"auto_executable": schema.BoolAttribute{
+				Description: "Boolean flag indicating whether archived files starting with a shebang (`#!`) or the " +
+					"header of an ELF or Mach-O binary should get the mode `0755`, and every other file `0644`, " +
+					"regardless of their mode on disk. Takes precedence over `output_file_mode`. Defaults to `false`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"mtime_mode": schema.StringAttribute{
+				Description: "How to set the modification time of the archived files. `zero` records the zero time, " +
+					"stored as 1980-01-01 by zip and 0001-01-01 by tar, `fixed` records `mtime`, `source_date_epoch` " +
+					"records the time set by the `SOURCE_DATE_EPOCH` environment variable, and `preserve` records the " +
+					"modification time of the files on disk. Files of `entry` blocks with a `mtime` keep it. Zip " +
+					"archives cannot record times before 1980-01-01, which are an error unless preserved from the " +
+					"files on disk. Defaults to `zero`.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(MtimeModeZero, MtimeModeFixed, MtimeModeSourceDateEpoch, MtimeModePreserve),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"mtime": schema.StringAttribute{
+				Description: "RFC3339 timestamp to record as the modification time of the archived files when " +
+					"`mtime_mode` is `fixed`, for example `\"2024-01-01T00:00:00Z\"`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"owner": schema.StringAttribute{
+				Description: "The user owning the files of tar archives, given as an ID, a name, or a `name:ID` pair " +
+					"like the `--owner` option of GNU tar, for example `\"1000\"`, `\"root\"` or `\"app:1000\"`. " +
+					"Names are recorded as is, with the ID `0` when none is given, rather than looked up. Defaults " +
+					"to the ID `0` with no name. Not supported by zip archives.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(ownerRegexp, "must be an ID, a name or a name:ID pair"),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"group": schema.StringAttribute{
+				Description: "The group owning the files of tar archives, given like `owner`. Defaults to the ID " +
+					"`0` with no name. Not supported by zip archives.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(ownerRegexp, "must be an ID, a name or a name:ID pair"),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"tar_format": schema.StringAttribute{
+				Description: "The format of the headers of `tar.gz` archives, one of `ustar`, `pax` or `gnu`. `ustar` " +
+					"is the most widely readable, but cannot represent paths longer than 256 characters among other " +
+					"limits, and files it cannot represent are an error. `pax` and `gnu` represent long paths with " +
+					"extension headers. By default, the format of each file is the most widely readable one which " +
+					"can represent it.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(TarFormatUSTAR, TarFormatPAX, TarFormatGNU),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"preserve_xattrs": schema.BoolAttribute{
+				Description: "Boolean flag indicating whether the extended attributes of the archived files, such as " +
+					"file capabilities (`security.capability`) and SELinux labels (`security.selinux`), should be " +
+					"recorded as `SCHILY.xattr.*` PAX records of `tar.gz` archives. Extended attributes are only " +
+					"read on Linux, and are not supported by zip archives. Defaults to `false`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"xattr_namespaces": schema.SetAttribute{
+				Description: "The namespaces of the extended attributes recorded when `preserve_xattrs` is set: " +
+					"`security`, `system` (including POSIX ACLs), `trusted` (only readable with `CAP_SYS_ADMIN`) " +
+					"or `user`. Defaults to `security` and `user`. Not supported by zip archives.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Set{
+					setvalidator.ValueStringsAre(
+						stringvalidator.OneOf(XattrNamespaceSecurity, XattrNamespaceSystem, XattrNamespaceTrusted,
+							XattrNamespaceUser),
+					),
+					setvalidator.AlsoRequires(fwpath.MatchRoot("preserve_xattrs")),
+				},
+				PlanModifiers: []planmodifier.Set{
+					setplanmodifier.RequiresReplace(),
+				},
+			},
+			"preserve_hardlinks": schema.BoolAttribute{
+				Description: "Boolean flag indicating whether archived files which are hard links to the same file " +
+					"should be stored once in `tar.gz` archives, the first of them in the archive as a file and the " +
+					"others as hard links to it. Hard links are only detected on Unix systems, and are not supported by " +
+					"zip archives. Defaults to `false`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
 			"output_md5": schema.StringAttribute{
 				Description: "MD5 of output file",
 				Computed:    true,
@@ -266,7 +1245,7 @@ func updateModel(ctx context.Context, model *fileModel) diag.Diagnostics {
 		}
 	}
 
-	if err := archive(ctx, *model); err != nil {
+	if err := archive(ctx, model); err != nil {
 		diags.AddError(
 			"Archive creation error",
 			fmt.Sprintf("error creating archive: %s", err),
@@ -274,7 +1253,8 @@ func updateModel(ctx context.Context, model *fileModel) diag.Diagnostics {
 		return diags
 	}
 
-	// Generate archived file stats
+	// The size and checksums are those of the whole archive, before it is split, as the volumes of split zip
+	// archives record offsets relative to each volume rather than being a plain split of the archive.
 	fi, err := os.Stat(outputPath)
 	if err != nil {
 		diags.AddError(
@@ -293,6 +1273,36 @@ func updateModel(ctx context.Context, model *fileModel) diag.Diagnostics {
 		)
 		return diags
 	}
+
+	model.OutputParts = types.ListNull(types.ObjectType{AttrTypes: outputPartAttrTypes})
+
+	if model.SplitSize.IsNull() {
+		// Remove the volumes of a previous run which set split_size.
+		if err := removeStaleParts(model.Type.ValueString(), outputPath); err != nil {
+			diags.AddError(
+				"Archive split error",
+				fmt.Sprintf("error splitting archive: %s", err),
+			)
+			return diags
+		}
+	} else {
+		outputParts, err := splitArchive(model.Type.ValueString(), outputPath, model.SplitSize.ValueInt64())
+		if err != nil {
+			diags.AddError(
+				"Archive split error",
+				fmt.Sprintf("error splitting archive: %s", err),
+			)
+			return diags
+		}
+
+		var partsDiags diag.Diagnostics
+		model.OutputParts, partsDiags = outputPartsValue(ctx, outputParts)
+		diags.Append(partsDiags...)
+		if diags.HasError() {
+			return diags
+		}
+	}
+
 	model.OutputMd5 = types.StringValue(checksums.md5Hex)
 	model.OutputSha = types.StringValue(checksums.sha1Hex)
 	model.OutputSha256 = types.StringValue(checksums.sha256Hex)
@@ -305,6 +1315,39 @@ func updateModel(ctx context.Context, model *fileModel) diag.Diagnostics {
 	return diags
 }
 
+// ModifyPlan plans the prior state when the configuration didn't change once the attribute plan modifiers ran, such
+// as when only the order of glob excludes changed, as the computed attributes would otherwise be planned as unknown.
+func (d *archiveFileResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan, state fileModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.ID = state.ID
+	plan.OutputSize = state.OutputSize
+	plan.OutputParts = state.OutputParts
+	plan.IgnoreFilesUsed = state.IgnoreFilesUsed
+	plan.SourceGitCommit = state.SourceGitCommit
+	plan.OutputMd5 = state.OutputMd5
+	plan.OutputSha = state.OutputSha
+	plan.OutputSha256 = state.OutputSha256
+	plan.OutputBase64Sha256 = state.OutputBase64Sha256
+	plan.OutputSha512 = state.OutputSha512
+	plan.OutputBase64Sha512 = state.OutputBase64Sha512
+
+	planned := tfsdk.Plan{Schema: req.Plan.Schema}
+	resp.Diagnostics.Append(planned.Set(ctx, plan)...)
+	if !resp.Diagnostics.HasError() && planned.Raw.Equal(req.State.Raw) {
+		resp.Plan = planned
+	}
+}
+
 func (d *archiveFileResource) Update(_ context.Context, _ resource.UpdateRequest, _ *resource.UpdateResponse) {
 }
 
@@ -4,13 +4,20 @@
 package archive
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	r "github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 )
 
 func TestResource_UpgradeFromVersion2_2_0_ContentConfig(t *testing.T) {
@@ -267,7 +274,7 @@ func TestResource_SourceConfigMissing(t *testing.T) {
 		Steps: []r.TestStep{
 			{
 				Config:      testResourceSourceConfigMissing("zip"),
-				ExpectError: regexp.MustCompile(`.*At least one of these attributes must be configured:\n\[source,source_content_filename,source_file,source_dir]`),
+				ExpectError: regexp.MustCompile(`.*At least one of these attributes must be configured:\n\[source,source_content_filename,source_file,source_dir,python_wheels,source_directory,source_git,source_archive,source_url,entry]`),
 			},
 		},
 	})
@@ -285,6 +292,1438 @@ func TestResource_SourceConfigConflicting(t *testing.T) {
 	})
 }
 
+func TestResource_PythonWheels(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_python_wheels.zip")
+	wheel := createTestWheel(t, "six-1.16.0-py2.py3-none-any.whl", "six-1.16.0", map[string]string{
+		"six.py": "# six",
+	})
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type = "zip"
+  source {
+    filename = "handler.py"
+    content  = "import six"
+  }
+  python_wheels {
+    paths = ["%s"]
+  }
+  output_path = "%s"
+}
+`, filepath.ToSlash(wheel), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					wheelMetadata := "Wheel-Version: 1.0\nRoot-Is-Purelib: true\n"
+					record := fmt.Sprintf("six-1.16.0.dist-info/WHEEL,sha256=%s,%d\nsix.py,sha256=%s,5\nsix-1.16.0.dist-info/RECORD,,\n",
+						recordHash(wheelMetadata), len(wheelMetadata), recordHash("# six"))
+
+					ensureContents(t, value, map[string][]byte{
+						"handler.py":                  []byte("import six"),
+						"six.py":                      []byte("# six"),
+						"six-1.16.0.dist-info/WHEEL":  []byte(wheelMetadata),
+						"six-1.16.0.dist-info/RECORD": []byte(record),
+					})
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestResource_PythonWheels_Conflict(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_python_wheels_conflict.zip")
+	first := createTestWheel(t, "first-1.0-py3-none-any.whl", "first-1.0", map[string]string{
+		"shared/__init__.py": "first",
+	})
+	second := createTestWheel(t, "second-1.0-py3-none-any.whl", "second-1.0", map[string]string{
+		"shared/__init__.py": "second",
+	})
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type = "zip"
+  python_wheels {
+    paths = ["%s"]
+  }
+  python_wheels {
+    paths = ["%s"]
+  }
+  output_path = "%s"
+}
+`, filepath.ToSlash(first), filepath.ToSlash(second), filepath.ToSlash(f)),
+				ExpectError: regexp.MustCompile(`shared/__init__.py\s+\(from\s+python_wheels\s+1\s+and\s+python_wheels\s+2\)`),
+			},
+		},
+	})
+}
+
+func TestResource_SplitSize(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "tar_file_acc_test_split_size.tar.gz")
+	z := filepath.Join(td, "zip_file_acc_test_split_size.zip")
+	src := filepath.Join(td, "file.bin")
+	if err := os.WriteFile(src, randomContent(200*1024), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := func(archiveType, outputPath, splitSize string) string {
+		return fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type        = "%s"
+  source_file = "%s"
+  split_size  = %s
+  output_path = "%s"
+}
+`, archiveType, filepath.ToSlash(src), splitSize, filepath.ToSlash(outputPath))
+	}
+
+	// The checksums are those of the archive before it is split, which are the same without split_size.
+	var tarSHA256, zipSHA256 string
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: config("tar.gz", f, "65536"),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("archive_file.foo", "output_parts.#", "4"),
+					r.TestCheckResourceAttr("archive_file.foo", "output_parts.0.path", filepath.ToSlash(f)+".001"),
+					r.TestCheckResourceAttr("archive_file.foo", "output_parts.0.size", "65536"),
+					r.TestCheckResourceAttr("archive_file.foo", "output_parts.3.path", filepath.ToSlash(f)+".004"),
+					r.TestCheckResourceAttrWith("archive_file.foo", "output_size", func(value string) error {
+						var total int64
+						for i := 1; i <= 4; i++ {
+							fi, err := os.Stat(fmt.Sprintf("%s.%03d", f, i))
+							if err != nil {
+								return err
+							}
+							total += fi.Size()
+						}
+						if value != fmt.Sprint(total) {
+							return fmt.Errorf("expected output_size %d, got %s", total, value)
+						}
+						return nil
+					}),
+					r.TestCheckResourceAttrWith("archive_file.foo", "output_sha256", func(value string) error {
+						h := sha256.New()
+						for i := 1; i <= 4; i++ {
+							data, err := os.ReadFile(fmt.Sprintf("%s.%03d", f, i))
+							if err != nil {
+								return err
+							}
+							h.Write(data)
+						}
+						if want := hex.EncodeToString(h.Sum(nil)); value != want {
+							return fmt.Errorf("expected output_sha256 %s of the concatenated volumes, got %s", want, value)
+						}
+						tarSHA256 = value
+						return nil
+					}),
+				),
+			},
+			{
+				Config: config("tar.gz", f, "null"),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckNoResourceAttr("archive_file.foo", "output_parts"),
+					r.TestCheckResourceAttrPtr("archive_file.foo", "output_sha256", &tarSHA256),
+					func(_ *terraform.State) error {
+						if _, err := os.Stat(f + ".001"); !os.IsNotExist(err) {
+							return fmt.Errorf("expected the volumes to be removed, got: %v", err)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: config("zip", z, "65536"),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("archive_file.foo", "output_parts.#", "4"),
+					r.TestCheckResourceAttr("archive_file.foo", "output_parts.3.path", filepath.ToSlash(z)),
+					r.TestCheckResourceAttrWith("archive_file.foo", "output_sha256", func(value string) error {
+						zipSHA256 = value
+						return nil
+					}),
+				),
+			},
+			{
+				Config: config("zip", z, "null"),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrPtr("archive_file.foo", "output_sha256", &zipSHA256),
+					r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+						if _, err := os.Stat(strings.TrimSuffix(value, ".zip") + ".z01"); !os.IsNotExist(err) {
+							return fmt.Errorf("expected the volumes to be removed, got: %v", err)
+						}
+						return nil
+					}),
+				),
+			},
+		},
+	})
+}
+
+func TestResource_SourceDirectory(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_source_directory.zip")
+	src, config := filepath.Join(td, "src"), filepath.Join(td, "config")
+	writeTestFiles(t, src, map[string]string{
+		"index.js":        "exports.handler = () => {}",
+		"index.test.js":   "test()",
+		"lib/response.js": "module.exports = {}",
+	})
+	writeTestFiles(t, config, map[string]string{
+		"app.json": "{}",
+	})
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type = "zip"
+  source_directory {
+    path     = "%s"
+    excludes = ["*.test.js"]
+  }
+  source_directory {
+    path          = "%s"
+    target_prefix = "config"
+  }
+  output_path = "%s"
+}
+`, filepath.ToSlash(src), filepath.ToSlash(config), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"config/app.json": []byte("{}"),
+						"index.js":        []byte("exports.handler = () => {}"),
+						"lib/response.js": []byte("module.exports = {}"),
+					})
+					return nil
+				}),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type = "zip"
+  source_directory {
+    path = "%s"
+  }
+  source_directory {
+    path = "%s"
+  }
+  output_path = "%s"
+}
+`, filepath.ToSlash(src), filepath.ToSlash(src), filepath.ToSlash(f)),
+				ExpectError: regexp.MustCompile(`conflicting files in\s+source directories`),
+			},
+		},
+	})
+}
+
+func TestResource_Entry(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_entry.zip")
+	writeTestFiles(t, td, map[string]string{
+		"handler.py":       "def handler(): pass",
+		"templates/a.html": "<a>",
+	})
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type = "zip"
+  source {
+    filename = "VERSION"
+    content  = "1.0.0"
+  }
+  entry {
+    file   = "%[1]s/handler.py"
+    target = "app/main.py"
+  }
+  entry {
+    directory = "%[1]s/templates"
+    target    = "app/templates"
+  }
+  entry {
+    content_base64 = "AAEC"
+    target         = "app/data.bin"
+    mode           = "0600"
+    mtime          = "2024-01-01T00:00:00Z"
+  }
+  entry {
+    content = "locked"
+    target  = "app/locked.txt"
+    mode    = "0000"
+  }
+  output_path = "%[2]s"
+}
+`, filepath.ToSlash(td), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"VERSION":              []byte("1.0.0"),
+						"app/main.py":          []byte("def handler(): pass"),
+						"app/templates/a.html": []byte("<a>"),
+						"app/data.bin":         {0, 1, 2},
+						"app/locked.txt":       []byte("locked"),
+					})
+					ensureFileModes(t, value, map[string]os.FileMode{
+						"app/data.bin":   0600,
+						"app/locked.txt": 0,
+					})
+					return nil
+				}),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type       = "zip"
+  source_dir = "%[1]s/templates"
+  source_directory {
+    path          = "%[1]s/templates"
+    target_prefix = "copy"
+  }
+  entry {
+    content = "1.0.0"
+    target  = "VERSION"
+  }
+  output_path = "%[2]s"
+}
+`, filepath.ToSlash(td), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"VERSION":     []byte("1.0.0"),
+						"a.html":      []byte("<a>"),
+						"copy/a.html": []byte("<a>"),
+					})
+					return nil
+				}),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type = "zip"
+  entry {
+    content = "first"
+    target  = "app.txt"
+  }
+  entry {
+    content = "second"
+    target  = "app.txt"
+  }
+  output_path = "%s"
+}
+`, filepath.ToSlash(f)),
+				ExpectError: regexp.MustCompile(`app.txt \(from entry 1 and entry 2\)`),
+			},
+		},
+	})
+}
+
+func TestResource_ContentBase64(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_content_base64.zip")
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type                    = "zip"
+  source_content_base64   = "not base64!"
+  source_content_filename = "data.bin"
+  output_path             = "%s"
+}
+`, filepath.ToSlash(f)),
+				ExpectError: regexp.MustCompile(`must be base64-encoded`),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type = "zip"
+  source {
+    filename = "README"
+    content  = "binary data"
+  }
+  source {
+    filename       = "data.bin"
+    content_base64 = "AP8Q"
+  }
+  output_path = "%s"
+}
+`, filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"README":   []byte("binary data"),
+						"data.bin": {0x00, 0xff, 0x10},
+					})
+					return nil
+				}),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type                    = "zip"
+  source_content_base64   = "AP8Q"
+  source_content_filename = "data.bin"
+  output_path             = "%s"
+}
+`, filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"data.bin": {0x00, 0xff, 0x10},
+					})
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestResource_Includes(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_includes.zip")
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type        = "zip"
+  source_dir  = "%s"
+  includes    = ["**/*.py", "templates/**"]
+  excludes    = ["tests/**"]
+  output_path = "%s"
+}
+`, filepath.ToSlash(createTestIncludesDir(t)), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"main.py":              []byte("main"),
+						"pkg/util.py":          []byte("util"),
+						"templates/index.html": []byte("index"),
+					})
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestResource_ExcludesGitignore(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_excludes_gitignore.zip")
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type            = "zip"
+  source_dir      = "%s"
+  excludes        = ["*.py", "!main.py", "tests/", "pkg/*", "!pkg/data.json"]
+  excludes_syntax = "gitignore"
+  output_path     = "%s"
+}
+`, filepath.ToSlash(createTestIncludesDir(t)), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"main.py":              []byte("main"),
+						"README.md":            []byte("readme"),
+						"pkg/data.json":        []byte("{}"),
+						"templates/index.html": []byte("index"),
+					})
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestResource_ExcludesOrder(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.ToSlash(filepath.Join(td, "zip_file_acc_test_excludes_order.zip"))
+	dir := filepath.ToSlash(createTestIncludesDir(t))
+	config := func(syntax string, excludes string) string {
+		return fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type            = "zip"
+  source_dir      = "%s"
+  excludes        = %s
+  excludes_syntax = "%s"
+  output_path     = "%s"
+}
+`, dir, excludes, syntax, f)
+	}
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: config(ExcludesSyntaxGlob, `["tests/**", "pkg/*.py"]`),
+			},
+			{
+				// The order of glob patterns doesn't matter.
+				Config:   config(ExcludesSyntaxGlob, `["pkg/*.py", "tests/**"]`),
+				PlanOnly: true,
+			},
+			{
+				Config: config(ExcludesSyntaxGitignore, `["*.py", "!main.py"]`),
+			},
+			{
+				Config:             config(ExcludesSyntaxGitignore, `["!main.py", "*.py"]`),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestResource_IgnoreFiles(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_ignore_files.zip")
+	dir := filepath.ToSlash(createTestIgnoreFilesDir(t))
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type         = "zip"
+  source_dir   = "%s"
+  ignore_files = [".gitignore", ".archiveignore"]
+  output_path  = "%s"
+}
+`, dir, filepath.ToSlash(f)),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+						ensureContents(t, value, testIgnoreFilesContents)
+						return nil
+					}),
+					r.TestCheckResourceAttr("archive_file.foo", "ignore_files_used.#", "3"),
+					r.TestCheckResourceAttr("archive_file.foo", "ignore_files_used.0", dir+"/.gitignore"),
+					r.TestCheckResourceAttr("archive_file.foo", "ignore_files_used.1", dir+"/.archiveignore"),
+					r.TestCheckResourceAttr("archive_file.foo", "ignore_files_used.2", dir+"/src/.gitignore"),
+				),
+			},
+		},
+	})
+}
+
+func TestResource_SourceGit(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_source_git.zip")
+	repo := createTestGitRepository(t)
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type        = "zip"
+  output_path = "%s"
+
+  source_git {
+    repository_path = "%s"
+    ref             = "v1-annotated"
+    subdirectory    = "src"
+  }
+}
+`, filepath.ToSlash(f), filepath.ToSlash(repo)),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+						ensureContents(t, value, map[string][]byte{
+							"main.py": []byte(testGitLargeFile("v1")),
+						})
+						return nil
+					}),
+					r.TestCheckResourceAttr("archive_file.foo", "source_git_commit", runGit(t, repo, "rev-parse", "v1^{commit}")),
+				),
+			},
+		},
+	})
+}
+
+func TestResource_SourceArchive(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "tar_file_acc_test_source_archive.tar.gz")
+	source := createTestSourceZip(t, filepath.Join(td, "source.zip"))
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type        = "tar.gz"
+  output_path = "%s"
+
+  source_archive {
+    path             = "%s"
+    excludes         = ["docs", "*.txt"]
+    strip_components = 1
+    target_prefix    = "vendor"
+  }
+}
+`, filepath.ToSlash(f), filepath.ToSlash(source)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureTarContents(t, value, map[string][]byte{
+						"vendor/README.md":   []byte("readme"),
+						"vendor/bin/run.sh":  []byte("#!/bin/sh\necho run\n"),
+						"vendor/lib/main.py": []byte("main"),
+					})
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestResource_SourceURL(t *testing.T) {
+	td := t.TempDir()
+	t.Setenv(sourceURLCacheDirEnv, filepath.Join(td, "cache"))
+
+	f := filepath.Join(td, "zip_file_acc_test_source_url.zip")
+	server, requests := newTestSourceURLServer(t)
+
+	config := func(sha256 string) string {
+		return fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type        = "zip"
+  output_path = "%s"
+
+  source_url {
+    url     = "%s/ca.pem"
+    sha256  = "%s"
+    target  = "certs/ca.pem"
+    mode    = "0600"
+    headers = {
+      Authorization = "Bearer token"
+    }
+  }
+}
+`, filepath.ToSlash(f), server.URL, sha256)
+	}
+
+	r.Test(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: config(testSourceURLSHA256),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+						ensureContents(t, value, map[string][]byte{
+							"certs/ca.pem": []byte(testSourceURLContent),
+						})
+						ensureFileModes(t, value, map[string]os.FileMode{
+							"certs/ca.pem": 0600,
+						})
+						return nil
+					}),
+					func(*terraform.State) error {
+						// Refreshing the resource reads the file from the cache.
+						if got := requests.Load(); got != 1 {
+							return fmt.Errorf("got %d requests, want 1", got)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config:      config(strings.Repeat("0", 64)),
+				ExpectError: regexp.MustCompile(`checksum mismatch for`),
+			},
+		},
+	})
+}
+
+func TestResource_ExcludeFileInfo(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_exclude_file_info.zip")
+	dir := filepath.Join(td, "src")
+	writeTestFiles(t, dir, map[string]string{
+		"main.py":        "main",
+		"data/large.bin": "0123456789abcdef",
+		"__init__.py":    "",
+	})
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type               = "zip"
+  source_dir         = "%s"
+  exclude_file_types = ["symlink"]
+  output_path        = "%s"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				ExpectError: regexp.MustCompile(`value must be one of`),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type                = "zip"
+  source_dir          = "%s"
+  exclude_larger_than = 8
+  exclude_older_than  = "720h"
+  exclude_file_types  = ["empty", "fifo", "socket", "device"]
+  output_path         = "%s"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"main.py": []byte("main"),
+					})
+					return nil
+				}),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type        = "zip"
+  output_path = "%s"
+
+  source_directory {
+    path               = "%s"
+    exclude_older_than = "yesterday"
+  }
+}
+`, filepath.ToSlash(f), filepath.ToSlash(dir)),
+				ExpectError: regexp.MustCompile(`invalid\s+exclude_older_than\s+"yesterday"`),
+			},
+		},
+	})
+}
+
+func TestResource_ExcludePresets(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_exclude_presets.zip")
+	dir := filepath.Join(td, "src")
+	writeTestFiles(t, dir, map[string]string{
+		"main.py":                      "main",
+		"pkg/util.py":                  "util",
+		"pkg/__pycache__/util.pyc":     "compiled",
+		".git/HEAD":                    "ref: refs/heads/main",
+		".DS_Store":                    "junk",
+		".terraform/terraform.tfstate": "{}",
+	})
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type            = "zip"
+  source_dir      = "%s"
+  exclude_presets = ["vcs", "python"]
+  excludes        = [".terraform"]
+  output_path     = "%s"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"main.py":     []byte("main"),
+						"pkg/util.py": []byte("util"),
+						".DS_Store":   []byte("junk"),
+					})
+					return nil
+				}),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type        = "zip"
+  output_path = "%s"
+
+  source_directory {
+    path            = "%s"
+    exclude_presets = ["vcs", "python", "terraform", "os_junk"]
+  }
+}
+`, filepath.ToSlash(f), filepath.ToSlash(dir)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"main.py":     []byte("main"),
+						"pkg/util.py": []byte("util"),
+					})
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestResource_PathTransform(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_path_transform.zip")
+	dir := filepath.Join(td, "src")
+	writeTestFiles(t, dir, map[string]string{
+		"dist/index.js":         "index",
+		"dist/config.prod.json": "prod",
+		"config.json":           "dev",
+	})
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type        = "zip"
+  source_dir  = "%s"
+  output_path = "%s"
+
+  path_transform {
+    match   = "^dist/"
+    replace = ""
+  }
+
+  path_transform {
+    match   = "\\.prod\\.json$"
+    replace = ".json"
+  }
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`config.json\s+\(from\s+.*config.json,\s+.*config.prod.json\)`),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type        = "zip"
+  output_path = "%s"
+
+  source {
+    content  = "dev"
+    filename = "config.json"
+  }
+
+  source {
+    content  = "prod"
+    filename = "config.prod.json"
+  }
+
+  path_transform {
+    match   = "\\.prod\\.json$"
+    replace = ".json"
+  }
+}
+`, filepath.ToSlash(f)),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`config.json\s+\(from\s+config.json,\s+config.prod.json\)`),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type        = "zip"
+  output_path = "%s"
+
+  source_directory {
+    path     = "%s"
+    excludes = ["config.json"]
+  }
+
+  path_transform {
+    match   = "^dist/"
+    replace = ""
+  }
+
+  path_transform {
+    match   = "\\.prod\\.json$"
+    replace = ".json"
+  }
+}
+`, filepath.ToSlash(f), filepath.ToSlash(dir)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"index.js":    []byte("index"),
+						"config.json": []byte("prod"),
+					})
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestResource_TargetPrefix(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_target_prefix.zip")
+	dir := filepath.Join(td, "src")
+	writeTestFiles(t, dir, map[string]string{
+		"README.md":       "readme",
+		"build/bin/app":   "app",
+		"build/lib/x.txt": "x",
+	})
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type             = "zip"
+  source_dir       = "%s"
+  target_prefix    = "myapp-1.2.3/"
+  strip_components = 1
+  output_path      = "%s"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"myapp-1.2.3/bin/app":   []byte("app"),
+						"myapp-1.2.3/lib/x.txt": []byte("x"),
+					})
+					return nil
+				}),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type               = "zip"
+  source_file        = "%s"
+  source_file_target = "myapp-1.2.3/docs/README.md"
+  output_path        = "%s"
+}
+`, filepath.ToSlash(filepath.Join(dir, "README.md")), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"myapp-1.2.3/docs/README.md": []byte("readme"),
+					})
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestResource_TargetPrefix_Invalid(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_target_prefix_invalid.zip")
+	dir := filepath.Join(td, "src")
+	writeTestFiles(t, dir, map[string]string{
+		"README.md": "readme",
+	})
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type          = "zip"
+  source_dir    = "%s"
+  target_prefix = "../../etc"
+  output_path   = "%s"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`file\s+name\s+in\s+archive\s+is\s+outside\s+of\s+the\s+archive:\s+../../etc`),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type = "zip"
+
+  source_directory {
+    path          = "%s"
+    target_prefix = "/etc"
+  }
+
+  output_path = "%s"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`must\s+be\s+relative\s+to\s+the\s+root\s+of\s+the\s+archive:\s+/etc`),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type = "zip"
+
+  entry {
+    content = "evil"
+    target  = "../evil.txt"
+  }
+
+  output_path = "%s"
+}
+`, filepath.ToSlash(f)),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`outside\s+of\s+the\s+archive:\s+../evil.txt`),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type = "zip"
+
+  source_url {
+    url    = "https://example.com/tool"
+    sha256 = "%s"
+    target = "/usr/bin/tool"
+  }
+
+  output_path = "%s"
+}
+`, strings.Repeat("0", 64), filepath.ToSlash(f)),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`must\s+be\s+relative\s+to\s+the\s+root\s+of\s+the\s+archive:\s+/usr/bin/tool`),
+			},
+		},
+	})
+}
+
+func TestResource_Flatten(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_flatten.zip")
+	dir := filepath.Join(td, "src")
+	writeTestFiles(t, dir, map[string]string{
+		"index.js":         "index",
+		"handlers/auth.js": "auth",
+		"handlers/util.js": "handlers util",
+		"lib/util.js":      "lib util",
+	})
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type        = "zip"
+  source_dir  = "%s"
+  flatten     = true
+  output_path = "%s"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`util.js\s+\(from\s+.*handlers.util.js,\s+.*lib.util.js\)`),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type         = "zip"
+  source_dir   = "%s"
+  flatten      = true
+  on_collision = "last"
+  output_path  = "%s"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureContents(t, value, map[string][]byte{
+						"index.js": []byte("index"),
+						"auth.js":  []byte("auth"),
+						"util.js":  []byte("lib util"),
+					})
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestResource_FileModeRule(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_file_mode_rule.zip")
+	dir := filepath.Join(td, "src")
+	writeTestFiles(t, dir, map[string]string{
+		"bootstrap":        "\x7fELF\x02\x01\x01",
+		"hooks/pre.sh":     "#!/bin/sh\necho pre\n",
+		"handler.py":       "def handler(event, context): pass\n",
+		"config/app.json":  "{}",
+		"config/creds.ini": "[default]",
+	})
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type             = "zip"
+  source_dir       = "%s"
+  output_path      = "%s"
+  output_file_mode = "0666"
+  auto_executable  = true
+
+  file_mode_rule {
+    pattern = "config/creds.ini"
+    mode    = "0600"
+  }
+
+  file_mode_rule {
+    pattern = "config/**"
+    mode    = "0640"
+  }
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureFileModes(t, value, map[string]os.FileMode{
+						"bootstrap":        0755,
+						"hooks/pre.sh":     0755,
+						"handler.py":       0644,
+						"config/app.json":  0640,
+						"config/creds.ini": 0600,
+					})
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestResource_MtimeMode(t *testing.T) {
+	td := t.TempDir()
+
+	zipPath := filepath.Join(td, "zip_file_acc_test_mtime_mode.zip")
+	tarPath := filepath.Join(td, "tar_file_acc_test_mtime_mode.tar.gz")
+	dir := filepath.Join(td, "src")
+	writeTestFiles(t, dir, map[string]string{
+		"main.py":    "main",
+		"lib/app.py": "app",
+	})
+
+	onDisk := time.Date(2023, 6, 15, 12, 30, 45, 0, time.UTC)
+	for _, name := range []string{"main.py", "lib/app.py"} {
+		if err := os.Chtimes(filepath.Join(dir, name), onDisk, onDisk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fixed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "zip" {
+  type        = "zip"
+  source_dir  = "%s"
+  output_path = "%s"
+  mtime_mode  = "fixed"
+  mtime       = "2024-01-01T00:00:00Z"
+}
+
+resource "archive_file" "tar" {
+  type        = "tar.gz"
+  source_dir  = "%s"
+  output_path = "%s"
+  mtime_mode  = "preserve"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(zipPath), filepath.ToSlash(dir), filepath.ToSlash(tarPath)),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrWith("archive_file.zip", "output_path", func(value string) error {
+						zr, err := zip.OpenReader(value)
+						if err != nil {
+							return err
+						}
+						defer zr.Close()
+
+						for _, f := range zr.File {
+							if !f.Modified.Equal(fixed) {
+								return fmt.Errorf("expected mtime %s for %s, got %s", fixed, f.Name, f.Modified)
+							}
+						}
+						return nil
+					}),
+					r.TestCheckResourceAttrWith("archive_file.tar", "output_path", func(value string) error {
+						for name, header := range tarHeaders(t, value) {
+							if !header.ModTime.Equal(onDisk) {
+								return fmt.Errorf("expected mtime %s for %s, got %s", onDisk, name, header.ModTime)
+							}
+						}
+						return nil
+					}),
+				),
+			},
+		},
+	})
+}
+
+func TestResource_Owner(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "tar_file_acc_test_owner.tar.gz")
+	dir := filepath.Join(td, "src")
+	writeTestFiles(t, dir, map[string]string{
+		"main.py":     "main",
+		"bin/install": "#!/bin/sh",
+	})
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type        = "zip"
+  source_dir  = "%s"
+  output_path = "%s"
+
+  owner_rule {
+    pattern = "bin/**"
+    owner   = "root"
+  }
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`owner_rule\s+is\s+not\s+supported\s+by\s+zip\s+archives`),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type        = "tar.gz"
+  source_dir  = "%s"
+  output_path = "%s"
+  owner       = "app:1000"
+  group       = "1000"
+
+  owner_rule {
+    pattern = "bin/**"
+    owner   = "root"
+  }
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					headers := tarHeaders(t, value)
+
+					if h := headers["main.py"]; h.Uid != 1000 || h.Uname != "app" || h.Gid != 1000 || h.Gname != "" {
+						return fmt.Errorf("unexpected owner of main.py: %d:%d (%s:%s)", h.Uid, h.Gid, h.Uname, h.Gname)
+					}
+					if h := headers["bin/install"]; h.Uid != 0 || h.Uname != "root" || h.Gid != 1000 {
+						return fmt.Errorf("unexpected owner of bin/install: %d:%d (%s:%s)", h.Uid, h.Gid, h.Uname, h.Gname)
+					}
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestResource_TarFormat(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "tar_file_acc_test_tar_format.tar.gz")
+	dir := filepath.Join(td, "src")
+	longName := strings.Repeat("nested/", 40) + "main.py"
+	writeTestFiles(t, dir, map[string]string{
+		"README.md": "readme",
+		longName:    "main",
+	})
+
+	config := func(format string) string {
+		return fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type        = "tar.gz"
+  source_dir  = "%s"
+  output_path = "%s"
+  tar_format  = "%s"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f), format)
+	}
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type        = "zip"
+  source_dir  = "%s"
+  output_path = "%s"
+  tar_format  = "pax"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`tar_format\s+is\s+not\s+supported\s+by\s+zip\s+archives`),
+			},
+			{
+				Config: config("gnu"),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					ensureTarContents(t, value, map[string][]byte{
+						"README.md": []byte("readme"),
+						longName:    []byte("main"),
+					})
+					return nil
+				}),
+			},
+			{
+				Config:      config("ustar"),
+				ExpectError: regexp.MustCompile(`nested/main\.py\s+cannot\s+be\s+archived\s+in\s+the\s+ustar\s+tar\s+format`),
+			},
+		},
+	})
+}
+
+func TestResource_SymlinkModePreserve(t *testing.T) {
+	td := t.TempDir()
+
+	zipPath := filepath.Join(td, "zip_file_acc_test_symlink_mode.zip")
+	tarPath := filepath.Join(td, "tar_file_acc_test_symlink_mode.tar.gz")
+	dir := filepath.Join(td, "src")
+	writeTestFiles(t, dir, map[string]string{
+		"node_modules/pkg/cli.js": "cli",
+	})
+	createTestSymlink(t, filepath.Join("..", "pkg", "cli.js"), filepath.Join(dir, "node_modules", ".bin", "pkg"))
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "zip" {
+  type         = "zip"
+  source_dir   = "%s"
+  output_path  = "%s"
+  symlink_mode = "preserve"
+}
+
+resource "archive_file" "tar" {
+  type         = "tar.gz"
+  source_dir   = "%s"
+  output_path  = "%s"
+  symlink_mode = "preserve"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(zipPath), filepath.ToSlash(dir), filepath.ToSlash(tarPath)),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrWith("archive_file.zip", "output_path", func(value string) error {
+						ensureContents(t, value, map[string][]byte{
+							"node_modules/.bin/pkg":   []byte("../pkg/cli.js"),
+							"node_modules/pkg/cli.js": []byte("cli"),
+						})
+
+						zr, err := zip.OpenReader(value)
+						if err != nil {
+							return err
+						}
+						defer zr.Close()
+
+						for _, f := range zr.File {
+							if f.Name == "node_modules/.bin/pkg" && f.Mode()&os.ModeSymlink == 0 {
+								return fmt.Errorf("expected symbolic link for %s, got mode %s", f.Name, f.Mode())
+							}
+						}
+						return nil
+					}),
+					r.TestCheckResourceAttrWith("archive_file.tar", "output_path", func(value string) error {
+						h := tarHeaders(t, value)["node_modules/.bin/pkg"]
+						if h == nil || h.Typeflag != tar.TypeSymlink || h.Linkname != "../pkg/cli.js" {
+							return fmt.Errorf("expected symbolic link to ../pkg/cli.js for node_modules/.bin/pkg, got %+v", h)
+						}
+						return nil
+					}),
+				),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "zip" {
+  type         = "zip"
+  source_dir   = "%s"
+  output_path  = "%s"
+  symlink_mode = "preserve"
+  flatten      = true
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(zipPath)),
+				ExpectError: regexp.MustCompile(`symbolic\s+link\s+pkg\s+points\s+outside\s+of\s+the\s+archive:\s+../pkg/cli.js`),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "zip" {
+  type             = "zip"
+  source_dir       = "%s"
+  output_path      = "%s"
+  symlink_mode     = "preserve"
+  strip_components = 2
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(zipPath)),
+				ExpectError: regexp.MustCompile(`symbolic\s+link\s+pkg\s+points\s+outside\s+of\s+the\s+archive:\s+../pkg/cli.js`),
+			},
+		},
+	})
+}
+
+func TestResource_IncludeDirectories(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "zip_file_acc_test_include_directories.zip")
+	dir := createTestDirectoriesDir(t)
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type                = "zip"
+  source_dir          = "%s"
+  output_path         = "%s"
+  excludes            = ["build/**"]
+  include_directories = true
+  directory_mode      = "0750"
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					zr, err := zip.OpenReader(value)
+					if err != nil {
+						return err
+					}
+					defer zr.Close()
+
+					modes := make(map[string]os.FileMode)
+					for _, f := range zr.File {
+						modes[f.Name] = f.Mode()
+					}
+
+					for _, name := range []string{"app/", "lib/", "logs/", "tmp/", "tmp/cache/"} {
+						if mode, ok := modes[name]; !ok || mode != os.ModeDir|0750 {
+							return fmt.Errorf("expected directory %s with mode 0750, got %s", name, mode)
+						}
+					}
+					return nil
+				}),
+			},
+		},
+	})
+}
+
 func alterFileContents(content, path string) {
 	f, err := os.Create(path)
 	if err != nil {
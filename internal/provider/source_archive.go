@@ -0,0 +1,272 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+type SourceArchiveOpts struct {
+	Excludes        []string
+	StripComponents int
+	TargetPrefix    string
+}
+
+// sourceArchiveEntries returns the files of an existing zip or tar archive, in the order they are stored, so that
+// they can be written to another archive. Files keep the modes recorded in the archive.
+func sourceArchiveEntries(archivePath string, opts SourceArchiveOpts) ([]ArchiveEntry, error) {
+	for _, exclude := range opts.Excludes {
+		if !doublestar.ValidatePattern(exclude) {
+			return nil, fmt.Errorf("invalid exclude pattern: %s", exclude)
+		}
+	}
+
+	prefix, err := cleanArchivePrefix(opts.TargetPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target prefix: %s", err)
+	}
+	opts.TargetPrefix = prefix
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("could not archive missing archive: %s", archivePath)
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(6)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error reading archive %s: %w", archivePath, err)
+	}
+
+	var entries []ArchiveEntry
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		entries, err = readZipEntries(archivePath)
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		var zr *gzip.Reader
+		zr, err = gzip.NewReader(br)
+		if err == nil {
+			entries, err = readTarEntries(zr)
+		}
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		entries, err = readTarEntries(bzip2.NewReader(br))
+	case bytes.HasPrefix(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0}):
+		err = errors.New("unsupported compression: xz")
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		err = errors.New("unsupported compression: zstd")
+	default:
+		entries, err = readTarEntries(br)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading archive %s: %w", archivePath, err)
+	}
+
+	var files []ArchiveEntry
+	for _, entry := range entries {
+		name, ok := stripComponents(entry.Name, opts.StripComponents)
+		if !ok {
+			continue
+		}
+
+		if isExcludedArchiveEntry(name, opts.Excludes) {
+			continue
+		}
+
+		entry.Name = path.Join(opts.TargetPrefix, name)
+
+		// The input archive is not trusted, and links which pointed inside of it can point outside of the new
+		// archive once stripped.
+		if entry.LinkTarget != "" {
+			if err := checkLinkTarget(entry.Name, entry.LinkTarget); err != nil {
+				return nil, fmt.Errorf("error reading archive %s: %w", archivePath, err)
+			}
+		}
+
+		files = append(files, entry)
+	}
+
+	return files, nil
+}
+
+func readZipEntries(archivePath string) ([]ArchiveEntry, error) {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var entries []ArchiveEntry
+	for _, f := range r.File {
+		fi := f.FileInfo()
+		if fi.IsDir() {
+			continue
+		}
+
+		name, err := cleanArchiveEntryName(f.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := ArchiveEntry{
+			Name:       name,
+			Content:    data,
+			SourceInfo: fi,
+		}
+
+		if fi.Mode()&os.ModeSymlink != 0 {
+			entry.Content = nil
+			entry.LinkTarget = string(data)
+		} else if !fi.Mode().IsRegular() {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func readTarEntries(r io.Reader) ([]ArchiveEntry, error) {
+	tr := tar.NewReader(r)
+
+	// Hard links refer to the content of a file stored earlier in the archive.
+	contents := make(map[string][]byte)
+
+	var entries []ArchiveEntry
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var entry ArchiveEntry
+		switch header.Typeflag {
+		case tar.TypeReg:
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			entry.Content = data
+		case tar.TypeLink:
+			linkname, err := cleanArchiveEntryName(header.Linkname)
+			if err != nil {
+				return nil, err
+			}
+			data, ok := contents[linkname]
+			if !ok {
+				return nil, fmt.Errorf("hard link %s refers to missing file %s", header.Name, header.Linkname)
+			}
+			entry.Content = data
+			header.Size = int64(len(data))
+		case tar.TypeSymlink:
+			entry.LinkTarget = header.Linkname
+		default:
+			// Directories are created implicitly, and devices or FIFOs cannot be archived.
+			continue
+		}
+
+		name, err := cleanArchiveEntryName(header.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.Name = name
+		entry.SourceInfo = header.FileInfo()
+		contents[name] = entry.Content
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// cleanArchiveEntryName returns the name of a file stored in an archive relative to its root, rejecting names which
+// would be outside of it.
+func cleanArchiveEntryName(name string) (string, error) {
+	cleaned := path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	if cleaned == "/" || strings.Contains(name, "\x00") {
+		return "", fmt.Errorf("invalid file name in archive: %q", name)
+	}
+
+	for _, segment := range strings.Split(strings.ReplaceAll(name, "\\", "/"), "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("file name in archive is outside of the archive: %s", name)
+		}
+	}
+
+	return strings.TrimPrefix(cleaned, "/"), nil
+}
+
+// cleanArchivePath returns the configured name of a file inside the archive, relative to its root. Unlike names read
+// from archives, absolute names are rejected rather than made relative, as they are more likely a mistake.
+func cleanArchivePath(name string) (string, error) {
+	if path.IsAbs(strings.ReplaceAll(name, "\\", "/")) || filepath.IsAbs(name) {
+		return "", fmt.Errorf("file name in archive must be relative to the root of the archive: %s", name)
+	}
+
+	return cleanArchiveEntryName(name)
+}
+
+// cleanArchivePrefix returns the configured directory files are archived below, relative to the root of the archive,
+// which an empty prefix or "." stands for.
+func cleanArchivePrefix(prefix string) (string, error) {
+	if path.Clean(strings.ReplaceAll(prefix, "\\", "/")) == "." {
+		return "", nil
+	}
+
+	return cleanArchivePath(prefix)
+}
+
+// stripComponents removes the first n directories of name, like `tar --strip-components` does. It reports false when
+// nothing of name is left.
+func stripComponents(name string, n int) (string, bool) {
+	segments := strings.Split(name, "/")
+	if n >= len(segments) {
+		return "", false
+	}
+
+	return strings.Join(segments[n:], "/"), true
+}
+
+// isExcludedArchiveEntry reports whether name, or one of the directories containing it, matches one of the excludes.
+func isExcludedArchiveEntry(name string, excludes []string) bool {
+	for ; name != "."; name = path.Dir(name) {
+		for _, exclude := range excludes {
+			if exclude == "" {
+				continue
+			}
+
+			if isMatch, _ := doublestar.Match(exclude, name); isMatch {
+				return true
+			}
+		}
+	}
+
+	return false
+}
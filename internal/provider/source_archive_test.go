@@ -0,0 +1,338 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSourceArchiveEntries(t *testing.T) {
+	dir := t.TempDir()
+
+	archives := map[string]string{
+		"zip":    createTestSourceZip(t, filepath.Join(dir, "source.zip")),
+		"tar":    createTestSourceTar(t, filepath.Join(dir, "source.tar"), false),
+		"tar.gz": createTestSourceTar(t, filepath.Join(dir, "source.tar.gz"), true),
+	}
+
+	// There is no bzip2 writer in the standard library, so compress a tar with the bzip2 command when installed.
+	if _, err := exec.LookPath("bzip2"); err == nil {
+		tarPath := createTestSourceTar(t, filepath.Join(dir, "source-bz2.tar"), false)
+		if out, err := exec.Command("bzip2", tarPath).CombinedOutput(); err != nil {
+			t.Fatalf("bzip2: %s\n%s", err, out)
+		}
+		archives["tar.bz2"] = tarPath + ".bz2"
+	}
+
+	for name, archivePath := range archives {
+		t.Run(name, func(t *testing.T) {
+			entries, err := sourceArchiveEntries(archivePath, SourceArchiveOpts{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			ensureEntries(t, entries, map[string]string{
+				"vendor-1.0/README.md":   "readme",
+				"vendor-1.0/bin/run.sh":  "#!/bin/sh\necho run\n",
+				"vendor-1.0/docs/a.md":   "docs",
+				"vendor-1.0/latest.txt":  "-> README.md",
+				"vendor-1.0/lib/main.py": "main",
+			})
+
+			modes := map[string]os.FileMode{
+				"vendor-1.0/README.md":  0644,
+				"vendor-1.0/bin/run.sh": 0755,
+				"vendor-1.0/latest.txt": os.ModeSymlink | 0777,
+			}
+			for _, entry := range entries {
+				if want, ok := modes[entry.Name]; ok && entry.SourceInfo.Mode() != want {
+					t.Errorf("%s: got mode %s, want %s", entry.Name, entry.SourceInfo.Mode(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestSourceArchiveEntries_Opts(t *testing.T) {
+	archivePath := createTestSourceTar(t, filepath.Join(t.TempDir(), "source.tar.gz"), true)
+
+	entries, err := sourceArchiveEntries(archivePath, SourceArchiveOpts{
+		Excludes:        []string{"docs", "**/*.sh"},
+		StripComponents: 1,
+		TargetPrefix:    "vendor",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureEntries(t, entries, map[string]string{
+		"vendor/README.md":   "readme",
+		"vendor/latest.txt":  "-> README.md",
+		"vendor/lib/main.py": "main",
+	})
+}
+
+func TestSourceArchiveEntries_HardLink(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "hardlink.tar")
+	writeTestTar(t, archivePath, false, []*tar.Header{
+		{Name: "a.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 4},
+		{Name: "b.txt", Typeflag: tar.TypeLink, Linkname: "a.txt", Mode: 0644},
+	}, map[string]string{"a.txt": "data"})
+
+	entries, err := sourceArchiveEntries(archivePath, SourceArchiveOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureEntries(t, entries, map[string]string{
+		"a.txt": "data",
+		"b.txt": "data",
+	})
+}
+
+func TestSourceArchiveEntries_SymlinkOutside(t *testing.T) {
+	dir := t.TempDir()
+
+	testCases := []struct {
+		name            string
+		target          string
+		stripComponents int
+		wantErr         bool
+	}{
+		{name: "vendor-1.0/lib/link", target: "../README.md", stripComponents: 1},
+		{name: "vendor-1.0/lib/link", target: "../README.md", stripComponents: 2, wantErr: true},
+		{name: "link", target: "../../../etc", wantErr: true},
+		{name: "link", target: "/etc/passwd", wantErr: true},
+	}
+
+	for i, tc := range testCases {
+		tarPath := filepath.Join(dir, fmt.Sprintf("link-%d.tar", i))
+		writeTestTar(t, tarPath, false, []*tar.Header{
+			{Name: tc.name, Typeflag: tar.TypeSymlink, Linkname: tc.target, Mode: 0777},
+		}, nil)
+
+		zipPath := filepath.Join(dir, fmt.Sprintf("link-%d.zip", i))
+		header := &zip.FileHeader{Name: tc.name}
+		header.SetMode(os.ModeSymlink | 0777)
+		writeTestZip(t, zipPath, []*zip.FileHeader{header}, map[string]string{tc.name: tc.target})
+
+		for _, archivePath := range []string{tarPath, zipPath} {
+			_, err := sourceArchiveEntries(archivePath, SourceArchiveOpts{StripComponents: tc.stripComponents})
+			if tc.wantErr && (err == nil || !strings.Contains(err.Error(), "points outside of the archive")) {
+				t.Errorf("%s: expected error for %s -> %s, got: %v", filepath.Base(archivePath), tc.name, tc.target, err)
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("%s: unexpected error: %s", filepath.Base(archivePath), err)
+			}
+		}
+	}
+}
+
+func TestSourceArchiveEntries_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	unsafe := filepath.Join(dir, "unsafe.tar")
+	writeTestTar(t, unsafe, false, []*tar.Header{
+		{Name: "../evil.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 4},
+	}, map[string]string{"../evil.txt": "evil"})
+
+	xz := filepath.Join(dir, "source.tar.xz")
+	if err := os.WriteFile(xz, []byte{0xfd, '7', 'z', 'X', 'Z', 0, 0, 0}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, archivePath := range []string{unsafe, xz, filepath.Join(dir, "missing.zip")} {
+		if _, err := sourceArchiveEntries(archivePath, SourceArchiveOpts{}); err == nil {
+			t.Errorf("%s: expected error", filepath.Base(archivePath))
+		}
+	}
+}
+
+func TestStripComponents(t *testing.T) {
+	testCases := []struct {
+		name string
+		n    int
+		want string
+		ok   bool
+	}{
+		{name: "a/b/c.txt", n: 0, want: "a/b/c.txt", ok: true},
+		{name: "a/b/c.txt", n: 1, want: "b/c.txt", ok: true},
+		{name: "a/b/c.txt", n: 2, want: "c.txt", ok: true},
+		{name: "a/b/c.txt", n: 3, ok: false},
+	}
+
+	for _, tc := range testCases {
+		got, ok := stripComponents(tc.name, tc.n)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("stripComponents(%q, %d) = %q, %t, want %q, %t", tc.name, tc.n, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestCleanArchivePath(t *testing.T) {
+	testCases := []struct {
+		name    string
+		prefix  bool
+		want    string
+		wantErr bool
+	}{
+		{name: "docs/README.md", want: "docs/README.md"},
+		{name: "./docs//README.md", want: "docs/README.md"},
+		{name: "docs\\README.md", want: "docs/README.md"},
+		{name: "myapp-1.2.3/", prefix: true, want: "myapp-1.2.3"},
+		{name: "", prefix: true, want: ""},
+		{name: ".", prefix: true, want: ""},
+		{name: "", wantErr: true},
+		{name: "../../etc", prefix: true, wantErr: true},
+		{name: "docs/../../etc/passwd", wantErr: true},
+		{name: "/etc", prefix: true, wantErr: true},
+		{name: "/etc/passwd", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		clean := cleanArchivePath
+		if tc.prefix {
+			clean = cleanArchivePrefix
+		}
+
+		got, err := clean(tc.name)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("clean(%q) = %q, %v, want %q, error %t", tc.name, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+// createTestSourceZip writes a zip with a top-level vendor-1.0 directory, as vendor archives usually have.
+func createTestSourceZip(t *testing.T, zipPath string) string {
+	t.Helper()
+
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for _, file := range testSourceArchiveFiles {
+		fh := &zip.FileHeader{Name: file.name, Method: zip.Deflate}
+		fh.SetMode(file.mode)
+
+		fw, err := w.CreateHeader(fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(fw, file.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return zipPath
+}
+
+// createTestSourceTar writes a tar with the same files as createTestSourceZip.
+func createTestSourceTar(t *testing.T, tarPath string, compress bool) string {
+	t.Helper()
+
+	var headers []*tar.Header
+	contents := make(map[string]string)
+	for _, file := range testSourceArchiveFiles {
+		header := &tar.Header{Name: file.name, Mode: int64(file.mode.Perm()), Typeflag: tar.TypeReg}
+		switch {
+		case file.mode.IsDir():
+			header.Typeflag = tar.TypeDir
+		case file.mode&os.ModeSymlink != 0:
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = file.content
+		default:
+			header.Size = int64(len(file.content))
+			contents[file.name] = file.content
+		}
+		headers = append(headers, header)
+	}
+
+	writeTestTar(t, tarPath, compress, headers, contents)
+	return tarPath
+}
+
+var testSourceArchiveFiles = []struct {
+	name    string
+	mode    os.FileMode
+	content string
+}{
+	{name: "vendor-1.0/", mode: os.ModeDir | 0755},
+	{name: "vendor-1.0/README.md", mode: 0644, content: "readme"},
+	{name: "vendor-1.0/bin/run.sh", mode: 0755, content: "#!/bin/sh\necho run\n"},
+	{name: "vendor-1.0/docs/a.md", mode: 0644, content: "docs"},
+	{name: "vendor-1.0/latest.txt", mode: os.ModeSymlink | 0777, content: "README.md"},
+	{name: "./vendor-1.0/lib/main.py", mode: 0644, content: "main"},
+}
+
+func writeTestTar(t *testing.T, tarPath string, compress bool, headers []*tar.Header, contents map[string]string) {
+	t.Helper()
+
+	f, err := os.Create(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	if compress {
+		gzw := gzip.NewWriter(f)
+		defer gzw.Close()
+		w = gzw
+	}
+
+	tw := tar.NewWriter(w)
+	for _, header := range headers {
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(tw, contents[header.Name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTestZip(t *testing.T, zipPath string, headers []*zip.FileHeader, contents map[string]string) {
+	t.Helper()
+
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for _, header := range headers {
+		fw, err := w.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(fw, contents[header.Name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
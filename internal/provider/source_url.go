@@ -0,0 +1,158 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sourceURLCacheDirEnv overrides the directory downloaded files are cached in.
+const sourceURLCacheDirEnv = "TF_ARCHIVE_CACHE_DIR"
+
+// sourceURLClient downloads files, with a timeout so that a stalled server cannot block the creation of the archive.
+var sourceURLClient = &http.Client{Timeout: 10 * time.Minute}
+
+type SourceURLOpts struct {
+	URL     string
+	SHA256  string
+	Headers map[string]string
+	// CacheDir defaults to the directory returned by sourceURLCacheDir.
+	CacheDir string
+}
+
+// sourceURLEntry returns the entry for a file downloaded from opts.URL, archived as target. The file is only
+// archived once its checksum matches opts.SHA256.
+func sourceURLEntry(ctx context.Context, target string, opts SourceURLOpts) (ArchiveEntry, error) {
+	name, err := cleanArchivePath(target)
+	if err != nil {
+		return ArchiveEntry{}, fmt.Errorf("invalid target: %s", err)
+	}
+
+	cachedPath, err := downloadSourceURL(ctx, opts)
+	if err != nil {
+		return ArchiveEntry{}, err
+	}
+
+	entry, err := fileEntry(cachedPath)
+	if err != nil {
+		return ArchiveEntry{}, err
+	}
+	entry.Name = name
+
+	return entry, nil
+}
+
+// downloadSourceURL returns the path of the cached copy of the file at opts.URL. Files are cached by checksum, so a
+// file which is already cached with the expected checksum is not downloaded again.
+func downloadSourceURL(ctx context.Context, opts SourceURLOpts) (string, error) {
+	want := strings.ToLower(opts.SHA256)
+
+	cacheDir := opts.CacheDir
+	if cacheDir == "" {
+		var err error
+		cacheDir, err = sourceURLCacheDir()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	cachedPath := filepath.Join(cacheDir, want)
+	if got, err := fileSHA256(cachedPath); err == nil && got == want {
+		return cachedPath, nil
+	}
+
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %s: %w", opts.URL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported URL scheme %q, must be http or https: %s", u.Scheme, opts.URL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, opts.URL, nil)
+	if err != nil {
+		return "", fmt.Errorf("error downloading %s: %w", opts.URL, err)
+	}
+	for name, value := range opts.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := sourceURLClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error downloading %s: %w", opts.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error downloading %s: unexpected status %s", opts.URL, resp.Status)
+	}
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", fmt.Errorf("error creating cache directory: %w", err)
+	}
+
+	// Download to a temporary file so that an interrupted or mismatched download is never used from the cache.
+	tmp, err := os.CreateTemp(cacheDir, want+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("error creating cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("error downloading %s: %w", opts.URL, err)
+	}
+
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return "", fmt.Errorf("checksum mismatch for %s:\n  expected sha256: %s\n  actual sha256:   %s", opts.URL, want, got)
+	}
+
+	// Cached files have the same mode regardless of the umask, so that archives do not depend on it.
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), cachedPath); err != nil {
+		return "", fmt.Errorf("error caching %s: %w", opts.URL, err)
+	}
+
+	return cachedPath, nil
+}
+
+// sourceURLCacheDir returns the directory downloaded files are cached in, which can be set with the
+// TF_ARCHIVE_CACHE_DIR environment variable.
+func sourceURLCacheDir() (string, error) {
+	if dir := os.Getenv(sourceURLCacheDirEnv); dir != "" {
+		return dir, nil
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine cache directory, set %s: %w", sourceURLCacheDirEnv, err)
+	}
+
+	return filepath.Join(dir, "terraform-provider-archive", "downloads"), nil
+}
+
+func fileSHA256(filename string) (string, error) {
+	h := sha256.New()
+	if err := copyFileTo(h, filename); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
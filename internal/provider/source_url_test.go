@@ -0,0 +1,150 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const testSourceURLContent = "-----BEGIN CERTIFICATE-----\n"
+
+var testSourceURLSHA256 = func() string {
+	sum := sha256.Sum256([]byte(testSourceURLContent))
+	return hex.EncodeToString(sum[:])
+}()
+
+func TestSourceURLEntry(t *testing.T) {
+	server, requests := newTestSourceURLServer(t)
+	cacheDir := t.TempDir()
+
+	opts := SourceURLOpts{
+		URL:      server.URL + "/ca.pem",
+		SHA256:   strings.ToUpper(testSourceURLSHA256),
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+		CacheDir: cacheDir,
+	}
+
+	for i := 0; i < 2; i++ {
+		entry, err := sourceURLEntry(context.Background(), "certs/ca.pem", opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if entry.Name != "certs/ca.pem" {
+			t.Errorf("got name %s, want certs/ca.pem", entry.Name)
+		}
+
+		data, err := os.ReadFile(entry.SourcePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != testSourceURLContent {
+			t.Errorf("got content %q, want %q", data, testSourceURLContent)
+		}
+	}
+
+	// The second entry is read from the cache.
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
+
+func TestSourceURLEntry_CorruptCache(t *testing.T) {
+	server, requests := newTestSourceURLServer(t)
+	cacheDir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(cacheDir, testSourceURLSHA256), []byte("corrupt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sourceURLEntry(context.Background(), "ca.pem", SourceURLOpts{
+		URL:      server.URL + "/ca.pem",
+		SHA256:   testSourceURLSHA256,
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+		CacheDir: cacheDir,
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
+
+func TestSourceURLEntry_ChecksumMismatch(t *testing.T) {
+	server, _ := newTestSourceURLServer(t)
+	cacheDir := t.TempDir()
+
+	_, err := sourceURLEntry(context.Background(), "ca.pem", SourceURLOpts{
+		URL:      server.URL + "/ca.pem",
+		SHA256:   strings.Repeat("0", 64),
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+		CacheDir: cacheDir,
+	})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") || !strings.Contains(err.Error(), testSourceURLSHA256) {
+		t.Fatalf("expected checksum mismatch error, got: %v", err)
+	}
+
+	// Mismatched downloads are not cached.
+	files, err := os.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected empty cache, found %d files", len(files))
+	}
+}
+
+func TestSourceURLEntry_Errors(t *testing.T) {
+	server, _ := newTestSourceURLServer(t)
+
+	testCases := map[string]SourceURLOpts{
+		"missing header": {URL: server.URL + "/ca.pem"},
+		"not found":      {URL: server.URL + "/missing.pem", Headers: map[string]string{"Authorization": "Bearer token"}},
+		"scheme":         {URL: "file:///etc/passwd"},
+	}
+
+	for name, opts := range testCases {
+		opts.SHA256 = testSourceURLSHA256
+		opts.CacheDir = t.TempDir()
+
+		if _, err := sourceURLEntry(context.Background(), "ca.pem", opts); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// newTestSourceURLServer serves testSourceURLContent at /ca.pem to requests with a bearer token, counting the
+// requests it receives.
+func newTestSourceURLServer(t *testing.T) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/ca.pem" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(testSourceURLContent))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
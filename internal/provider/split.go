@@ -0,0 +1,402 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// minSplitSize is the smallest volume size accepted for split_size, matching the minimum used by Info-ZIP.
+const minSplitSize = 64 * 1024
+
+const (
+	zipLocalHeaderSignature      = 0x04034b50
+	zipCentralHeaderSignature    = 0x02014b50
+	zipEndOfCentralDirSignature  = 0x06054b50
+	zipEndOfCentralDir64Locator  = 0x07064b50
+	zipSpanningSignature         = 0x08074b50
+	zipLocalHeaderLen            = 30
+	zipCentralHeaderLen          = 46
+	zipEndOfCentralDirLen        = 22
+	zipMaxEndOfCentralDirComment = 0xffff
+	zipDataDescriptorFlag        = 0x8
+)
+
+// splitArchive splits the archive written to outputPath into volumes of at most splitSize bytes and returns the
+// paths of the volumes in order.
+//
+// Zip archives are written as a standard split archive, in which all but the last volume are named with a .z01,
+// .z02, ... extension and the last volume keeps the name of outputPath. The volumes start with a spanning signature
+// and the central directory records offsets relative to each volume, so the concatenated volumes differ from the
+// original archive. When the archive already fits into a single volume it is left untouched. Other archive types are
+// split into outputPath.001, outputPath.002, ..., which concatenated yield the original archive.
+func splitArchive(archiveType, outputPath string, splitSize int64) ([]string, error) {
+	if splitSize <= 0 {
+		return nil, fmt.Errorf("invalid split size: %d", splitSize)
+	}
+
+	if err := removeStaleParts(archiveType, outputPath); err != nil {
+		return nil, err
+	}
+
+	if archiveType == "zip" {
+		return splitZip(outputPath, splitSize)
+	}
+
+	return splitFile(outputPath, splitSize)
+}
+
+func splitPartPath(archiveType, outputPath string, index int) string {
+	if archiveType == "zip" {
+		return fmt.Sprintf("%s.z%02d", strings.TrimSuffix(outputPath, filepath.Ext(outputPath)), index)
+	}
+	return fmt.Sprintf("%s.%03d", outputPath, index)
+}
+
+// removeStaleParts removes volumes left behind by a previous run which produced more volumes than the current one.
+func removeStaleParts(archiveType, outputPath string) error {
+	for i := 1; ; i++ {
+		part := splitPartPath(archiveType, outputPath, i)
+		if err := os.Remove(part); err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return fmt.Errorf("error removing previous archive volume: %s", err)
+		}
+	}
+}
+
+func splitFile(outputPath string, splitSize int64) ([]string, error) {
+	in, err := os.Open(outputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	fi, err := in.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []string
+	for remaining := fi.Size(); remaining > 0 || len(parts) == 0; remaining -= splitSize {
+		part := splitPartPath("", outputPath, len(parts)+1)
+		if err := copyToFile(part, in, min(remaining, splitSize)); err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+
+	in.Close()
+	if err := os.Remove(outputPath); err != nil {
+		return nil, err
+	}
+
+	return parts, nil
+}
+
+func copyToFile(filename string, r io.Reader, n int64) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.CopyN(f, r, n); err != nil {
+		return fmt.Errorf("error writing archive volume %s: %s", filename, err)
+	}
+
+	return f.Close()
+}
+
+type zipCentralHeader struct {
+	raw         []byte
+	localOffset int64
+	dataLen     int64
+}
+
+func splitZip(outputPath string, splitSize int64) ([]string, error) {
+	fi, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi.Size() <= splitSize {
+		return []string{outputPath}, nil
+	}
+
+	// The last volume is written to outputPath, so read the archive from a temporary copy.
+	source := outputPath + ".split"
+	if err := os.Rename(outputPath, source); err != nil {
+		return nil, err
+	}
+	defer os.Remove(source)
+
+	in, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	headers, eocd, err := readZipCentralDirectory(in, fi.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	w := &volumeWriter{
+		splitSize: splitSize,
+		partPath: func(index int) string {
+			return splitPartPath("zip", outputPath, index)
+		},
+	}
+	defer w.close()
+
+	spanning := make([]byte, 4)
+	binary.LittleEndian.PutUint32(spanning, zipSpanningSignature)
+	if _, _, err := w.writeRecord(spanning); err != nil {
+		return nil, err
+	}
+
+	for _, h := range headers {
+		local := make([]byte, zipLocalHeaderLen)
+		if _, err := in.ReadAt(local, h.localOffset); err != nil {
+			return nil, err
+		}
+		if binary.LittleEndian.Uint32(local) != zipLocalHeaderSignature {
+			return nil, fmt.Errorf("invalid zip local file header at offset %d", h.localOffset)
+		}
+
+		headerLen := zipLocalHeaderLen + int64(binary.LittleEndian.Uint16(local[26:])) + int64(binary.LittleEndian.Uint16(local[28:]))
+		header := make([]byte, headerLen)
+		if _, err := in.ReadAt(header, h.localOffset); err != nil {
+			return nil, err
+		}
+
+		// Record the sizes and checksum in the local header instead of a trailing data descriptor, as tools which
+		// reassemble split archives read them from there.
+		if flags := binary.LittleEndian.Uint16(header[6:]); flags&zipDataDescriptorFlag != 0 {
+			binary.LittleEndian.PutUint16(header[6:], flags&^zipDataDescriptorFlag)
+			binary.LittleEndian.PutUint16(h.raw[8:], flags&^zipDataDescriptorFlag)
+			copy(header[14:26], h.raw[16:28])
+		}
+
+		// Local headers must not span volumes, so they are written as a whole.
+		disk, offset, err := w.writeRecord(header)
+		if err != nil {
+			return nil, err
+		}
+		binary.LittleEndian.PutUint16(h.raw[34:], uint16(disk))
+		binary.LittleEndian.PutUint32(h.raw[42:], uint32(offset))
+
+		if err := w.writeData(in, h.localOffset+headerLen, h.dataLen); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		cdDisk, cdOffset int
+		cdSize           int64
+		entriesOnDisk    = map[int]int{}
+	)
+	for i, h := range headers {
+		disk, offset, err := w.writeRecord(h.raw)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			cdDisk, cdOffset = disk, int(offset)
+		}
+		cdSize += int64(len(h.raw))
+		entriesOnDisk[disk]++
+	}
+
+	if err := w.reserve(int64(len(eocd))); err != nil {
+		return nil, err
+	}
+	if len(headers) == 0 {
+		cdDisk, cdOffset = w.index-1, int(w.written)
+	}
+	binary.LittleEndian.PutUint16(eocd[4:], uint16(w.index-1))
+	binary.LittleEndian.PutUint16(eocd[6:], uint16(cdDisk))
+	binary.LittleEndian.PutUint16(eocd[8:], uint16(entriesOnDisk[w.index-1]))
+	binary.LittleEndian.PutUint16(eocd[10:], uint16(len(headers)))
+	binary.LittleEndian.PutUint32(eocd[12:], uint32(cdSize))
+	binary.LittleEndian.PutUint32(eocd[16:], uint32(cdOffset))
+	if _, _, err := w.writeRecord(eocd); err != nil {
+		return nil, err
+	}
+
+	if err := w.close(); err != nil {
+		return nil, err
+	}
+
+	// The last volume takes the name of the archive.
+	last := len(w.parts) - 1
+	if err := os.Rename(w.parts[last], outputPath); err != nil {
+		return nil, err
+	}
+	w.parts[last] = outputPath
+
+	return w.parts, nil
+}
+
+// readZipCentralDirectory returns the central directory headers of the archive, ordered by the position of their
+// local headers, along with the end of central directory record.
+func readZipCentralDirectory(r io.ReaderAt, size int64) ([]zipCentralHeader, []byte, error) {
+	searchLen := min(size, zipEndOfCentralDirLen+zipMaxEndOfCentralDirComment)
+	buf := make([]byte, searchLen)
+	if _, err := r.ReadAt(buf, size-searchLen); err != nil {
+		return nil, nil, err
+	}
+
+	eocdPos := -1
+	for i := len(buf) - zipEndOfCentralDirLen; i >= 0; i-- {
+		if binary.LittleEndian.Uint32(buf[i:]) == zipEndOfCentralDirSignature {
+			eocdPos = i
+			break
+		}
+	}
+	if eocdPos < 0 {
+		return nil, nil, errors.New("could not find zip end of central directory record")
+	}
+	if eocdPos >= 20 && binary.LittleEndian.Uint32(buf[eocdPos-20:]) == zipEndOfCentralDir64Locator {
+		return nil, nil, errors.New("splitting zip64 archives is not supported")
+	}
+
+	eocd := append([]byte(nil), buf[eocdPos:]...)
+	count := int(binary.LittleEndian.Uint16(eocd[10:]))
+	cdSize := int64(binary.LittleEndian.Uint32(eocd[12:]))
+	cdOffset := int64(binary.LittleEndian.Uint32(eocd[16:]))
+
+	cd := make([]byte, cdSize)
+	if _, err := r.ReadAt(cd, cdOffset); err != nil {
+		return nil, nil, err
+	}
+
+	headers := make([]zipCentralHeader, 0, count)
+	for pos := 0; len(headers) < count; {
+		if pos+zipCentralHeaderLen > len(cd) || binary.LittleEndian.Uint32(cd[pos:]) != zipCentralHeaderSignature {
+			return nil, nil, errors.New("invalid zip central directory")
+		}
+
+		headerLen := zipCentralHeaderLen +
+			int(binary.LittleEndian.Uint16(cd[pos+28:])) +
+			int(binary.LittleEndian.Uint16(cd[pos+30:])) +
+			int(binary.LittleEndian.Uint16(cd[pos+32:]))
+
+		raw := append([]byte(nil), cd[pos:pos+headerLen]...)
+		headers = append(headers, zipCentralHeader{
+			raw:         raw,
+			localOffset: int64(binary.LittleEndian.Uint32(raw[42:])),
+			dataLen:     int64(binary.LittleEndian.Uint32(raw[20:])),
+		})
+		pos += headerLen
+	}
+
+	sort.SliceStable(headers, func(i, j int) bool {
+		return headers[i].localOffset < headers[j].localOffset
+	})
+
+	return headers, eocd, nil
+}
+
+// volumeWriter writes a stream of records and data across numbered volumes of at most splitSize bytes.
+type volumeWriter struct {
+	splitSize int64
+	partPath  func(index int) string
+
+	parts   []string
+	index   int
+	written int64
+	file    *os.File
+}
+
+// reserve starts a new volume unless n bytes fit into the current one.
+func (w *volumeWriter) reserve(n int64) error {
+	if n > w.splitSize {
+		return fmt.Errorf("split size %d is too small to hold a zip record of %d bytes", w.splitSize, n)
+	}
+
+	if w.file == nil || w.written+n > w.splitSize {
+		return w.next()
+	}
+
+	return nil
+}
+
+// writeRecord writes b without splitting it across volumes and returns the volume number (starting at zero) and the
+// offset within that volume it was written to.
+func (w *volumeWriter) writeRecord(b []byte) (int, int64, error) {
+	if err := w.reserve(int64(len(b))); err != nil {
+		return 0, 0, err
+	}
+
+	disk, offset := w.index-1, w.written
+	if _, err := w.file.Write(b); err != nil {
+		return 0, 0, err
+	}
+	w.written += int64(len(b))
+
+	return disk, offset, nil
+}
+
+// writeData copies n bytes of r starting at offset, splitting them across as many volumes as needed.
+func (w *volumeWriter) writeData(r io.ReaderAt, offset, n int64) error {
+	for n > 0 {
+		if w.file == nil || w.written == w.splitSize {
+			if err := w.next(); err != nil {
+				return err
+			}
+		}
+
+		chunk := min(n, w.splitSize-w.written)
+		if _, err := io.Copy(w.file, io.NewSectionReader(r, offset, chunk)); err != nil {
+			return err
+		}
+
+		w.written += chunk
+		offset += chunk
+		n -= chunk
+	}
+
+	return nil
+}
+
+func (w *volumeWriter) next() error {
+	if err := w.close(); err != nil {
+		return err
+	}
+
+	w.index++
+	part := w.partPath(w.index)
+
+	f, err := os.Create(part)
+	if err != nil {
+		return err
+	}
+
+	w.file = f
+	w.written = 0
+	w.parts = append(w.parts, part)
+
+	return nil
+}
+
+func (w *volumeWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+
+	err := w.file.Close()
+	w.file = nil
+
+	return err
+}
@@ -0,0 +1,183 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitArchive_TarGz(t *testing.T) {
+	tarFilePath := filepath.Join(t.TempDir(), "archive-split.tar.gz")
+
+	archiver := NewTarGzArchiver(tarFilePath)
+	if err := archiver.ArchiveContent(randomContent(200*1024), "random.bin"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	whole, err := os.ReadFile(tarFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts, err := splitArchive("tar.gz", tarFilePath, minSplitSize)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	wantParts := []string{tarFilePath + ".001", tarFilePath + ".002", tarFilePath + ".003", tarFilePath + ".004"}
+	ensureParts(t, parts, wantParts, minSplitSize)
+
+	if !bytes.Equal(concatParts(t, parts), whole) {
+		t.Errorf("concatenated volumes do not match the archive")
+	}
+
+	if _, err := os.Stat(tarFilePath); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, got: %v", tarFilePath, err)
+	}
+}
+
+func TestSplitArchive_Zip(t *testing.T) {
+	zipFilePath := filepath.Join(t.TempDir(), "archive-split.zip")
+
+	archiver := NewZipArchiver(zipFilePath)
+	if err := archiver.ArchiveMultiple(map[string][]byte{
+		"file1.bin": randomContent(100 * 1024),
+		"file2.bin": randomContent(10 * 1024),
+		"file3.bin": randomContent(50 * 1024),
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	parts, err := splitArchive("zip", zipFilePath, minSplitSize)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	base := filepath.Join(filepath.Dir(zipFilePath), "archive-split")
+	ensureParts(t, parts, []string{base + ".z01", base + ".z02", zipFilePath}, minSplitSize)
+
+	volumes := make([][]byte, len(parts))
+	for i, part := range parts {
+		if volumes[i], err = os.ReadFile(part); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if binary.LittleEndian.Uint32(volumes[0]) != zipSpanningSignature {
+		t.Errorf("expected first volume to start with the spanning signature")
+	}
+
+	last := volumes[len(volumes)-1]
+	eocd := last[len(last)-zipEndOfCentralDirLen:]
+	if binary.LittleEndian.Uint32(eocd) != zipEndOfCentralDirSignature {
+		t.Fatalf("expected last volume to end with the end of central directory record")
+	}
+	if disk := int(binary.LittleEndian.Uint16(eocd[4:])); disk != len(volumes)-1 {
+		t.Errorf("unexpected disk number in end of central directory record, got %d, want %d", disk, len(volumes)-1)
+	}
+
+	cdDisk := binary.LittleEndian.Uint16(eocd[6:])
+	cdOffset := binary.LittleEndian.Uint32(eocd[16:])
+	cd := volumes[cdDisk][cdOffset:]
+
+	for i := 0; i < int(binary.LittleEndian.Uint16(eocd[10:])); i++ {
+		if binary.LittleEndian.Uint32(cd) != zipCentralHeaderSignature {
+			t.Fatalf("invalid central directory header %d", i)
+		}
+
+		disk := binary.LittleEndian.Uint16(cd[34:])
+		offset := binary.LittleEndian.Uint32(cd[42:])
+		if binary.LittleEndian.Uint32(volumes[disk][offset:]) != zipLocalHeaderSignature {
+			t.Errorf("central directory header %d does not point to a local file header", i)
+		}
+
+		cd = cd[zipCentralHeaderLen+int(binary.LittleEndian.Uint16(cd[28:]))+int(binary.LittleEndian.Uint16(cd[30:]))+int(binary.LittleEndian.Uint16(cd[32:])):]
+	}
+}
+
+func TestSplitArchive_Zip_SingleVolume(t *testing.T) {
+	zipFilePath := filepath.Join(t.TempDir(), "archive-split.zip")
+
+	archiver := NewZipArchiver(zipFilePath)
+	if err := archiver.ArchiveContent([]byte("This is some content"), "content.txt"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	parts, err := splitArchive("zip", zipFilePath, minSplitSize)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureParts(t, parts, []string{zipFilePath}, minSplitSize)
+	ensureContents(t, zipFilePath, map[string][]byte{
+		"content.txt": []byte("This is some content"),
+	})
+}
+
+func TestSplitArchive_RemovesStaleParts(t *testing.T) {
+	tarFilePath := filepath.Join(t.TempDir(), "archive-split.tar.gz")
+
+	for _, size := range []int{200 * 1024, 1024} {
+		archiver := NewTarGzArchiver(tarFilePath)
+		if err := archiver.ArchiveContent(randomContent(size), "random.bin"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if _, err := splitArchive("tar.gz", tarFilePath, minSplitSize); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if _, err := os.Stat(tarFilePath + ".002"); !os.IsNotExist(err) {
+		t.Errorf("expected stale volume to be removed, got: %v", err)
+	}
+}
+
+func randomContent(size int) []byte {
+	content := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(content)
+	return content
+}
+
+func ensureParts(t *testing.T, got, want []string, splitSize int64) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("mismatched volumes\ngot\n%s\nwant\n%s", got, want)
+	}
+
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("mismatched volume %d, got %s, want %s", i, got[i], want[i])
+		}
+
+		fi, err := os.Stat(got[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() > splitSize {
+			t.Errorf("volume %s is larger than %d bytes: %d", got[i], splitSize, fi.Size())
+		}
+	}
+}
+
+func concatParts(t *testing.T, parts []string) []byte {
+	t.Helper()
+
+	buf := bytes.Buffer{}
+	for _, part := range parts {
+		content, err := os.ReadFile(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(content)
+	}
+
+	return buf.Bytes()
+}
@@ -11,7 +11,6 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-	"sort"
 	"strconv"
 	"time"
 )
@@ -22,10 +21,23 @@ const (
 	TarCompressionGz TarCompressionType = iota
 )
 
+const (
+	TarFormatUSTAR = "ustar"
+	TarFormatPAX   = "pax"
+	TarFormatGNU   = "gnu"
+)
+
+var tarFormats = map[string]tar.Format{
+	TarFormatUSTAR: tar.FormatUSTAR,
+	TarFormatPAX:   tar.FormatPAX,
+	TarFormatGNU:   tar.FormatGNU,
+}
+
 type TarArchiver struct {
 	compression       TarCompressionType
 	filepath          string
 	outputFileMode    string // Default value "" means unset
+	format            string // Default value "" lets the tar writer pick the format of each header
 	fileWriter        *os.File
 	tarWriter         *tar.Writer
 	compressionWriter io.WriteCloser
@@ -43,173 +55,74 @@ func NewTarArchiver(filepath string, compression TarCompressionType) Archiver {
 }
 
 func (a *TarArchiver) ArchiveContent(content []byte, infilename string) error {
-	if err := a.open(); err != nil {
-		return err
-	}
-	defer a.close()
-
-	return a.addContent(content, &tar.Header{
-		Name:    infilename,
-		Size:    int64(len(content)),
-		ModTime: time.Time{},
-	})
+	return a.ArchiveEntries([]ArchiveEntry{{
+		Name:    filepath.ToSlash(infilename),
+		Content: content,
+	}})
 }
 
 func (a *TarArchiver) ArchiveFile(infilename string) error {
-	fi, err := assertValidFile(infilename)
+	entry, err := fileEntry(infilename)
 	if err != nil {
 		return err
 	}
 
-	if err := a.open(); err != nil {
-		return err
-	}
-	defer a.close()
-
-	header := &tar.Header{
-		Name:    filepath.ToSlash(fi.Name()),
-		Size:    fi.Size(),
-		Mode:    int64(fi.Mode()),
-		ModTime: time.Time{},
-	}
-
-	if err := a.addFile(infilename, header); err != nil {
-		return err
-	}
-
-	return err
+	return a.ArchiveEntries([]ArchiveEntry{entry})
 }
 
 func (a *TarArchiver) ArchiveDir(indirname string, opts ArchiveDirOpts) error {
-	err := assertValidDir(indirname)
+	entries, err := walkDir(indirname, opts)
 	if err != nil {
 		return err
 	}
 
-	// ensure exclusions are OS compatible paths
-	for i := range opts.Excludes {
-		opts.Excludes[i] = filepath.FromSlash(opts.Excludes[i])
+	if err := assertNotEmpty(entries); err != nil {
+		return err
 	}
 
-	// Determine whether an empty archive would be generated.
-	isArchiveEmpty := true
+	return a.ArchiveEntries(entries)
+}
 
-	err = filepath.Walk(indirname, a.createWalkFunc("", indirname, opts, &isArchiveEmpty, true))
+func (a *TarArchiver) ArchiveDirs(dirs []ArchiveDirSource) error {
+	entries, err := walkDirs(dirs)
 	if err != nil {
 		return err
 	}
 
-	// Return an error if an empty archive would be generated.
-	if isArchiveEmpty {
-		return fmt.Errorf("archive has not been created as it would be empty")
-	}
-
-	if err := a.open(); err != nil {
+	if err := assertNotEmpty(entries); err != nil {
 		return err
 	}
-	defer a.close()
 
-	return filepath.Walk(indirname, a.createWalkFunc("", indirname, opts, &isArchiveEmpty, false))
+	return a.ArchiveEntries(entries)
 }
 
-func (a *TarArchiver) createWalkFunc(basePath, indirname string, opts ArchiveDirOpts, isArchiveEmpty *bool, dryRun bool) func(path string, info os.FileInfo, err error) error {
-	return func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return fmt.Errorf("error encountered during file walk: %s", err)
-		}
-
-		relname, err := filepath.Rel(indirname, path)
-		if err != nil {
-			return fmt.Errorf("error relativizing file for archival: %s", err)
-		}
-
-		archivePath := filepath.Join(basePath, relname)
-
-		isMatch, err := checkMatch(archivePath, opts.Excludes)
-		if err != nil {
-			return fmt.Errorf("error checking excludes matches: %w", err)
-		}
-
-		if info.IsDir() {
-			if isMatch {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		if isMatch {
-			return nil
-		}
+func (a *TarArchiver) ArchiveMultiple(content map[string][]byte) error {
+	return a.ArchiveEntries(contentEntries(content))
+}
 
+func (a *TarArchiver) ArchiveEntries(entries []ArchiveEntry) error {
+	// Build every header before creating the archive, so that an entry which cannot be archived doesn't leave a
+	// truncated archive behind.
+	headers := make([]*tar.Header, len(entries))
+	for i, entry := range entries {
+		header, err := a.entryHeader(entry)
 		if err != nil {
 			return err
 		}
-
-		if info.Mode()&os.ModeSymlink == os.ModeSymlink {
-			realPath, err := filepath.EvalSymlinks(path)
-			if err != nil {
-				return err
-			}
-
-			realInfo, err := os.Stat(realPath)
-			if err != nil {
-				return err
-			}
-
-			if realInfo.IsDir() {
-				if !opts.ExcludeSymlinkDirectories {
-					return filepath.Walk(realPath, a.createWalkFunc(archivePath, realPath, opts, isArchiveEmpty, dryRun))
-				} else {
-					return filepath.SkipDir
-				}
-			}
-
-			info = realInfo
-		}
-
-		*isArchiveEmpty = false
-
-		if dryRun {
-			return nil
-		}
-
-		header := &tar.Header{
-			Name:    filepath.ToSlash(archivePath),
-			Size:    info.Size(),
-			Mode:    int64(info.Mode()),
-			ModTime: time.Time{},
-		}
-
-		return a.addFile(path, header)
+		headers[i] = header
 	}
-}
 
-func (a *TarArchiver) ArchiveMultiple(content map[string][]byte) error {
 	if err := a.open(); err != nil {
 		return err
 	}
 	defer a.close()
 
-	// Ensure files are processed in the same order so hashes don't change
-	keys := make([]string, len(content))
-	i := 0
-	for k := range content {
-		keys[i] = k
-		i++
-	}
-	sort.Strings(keys)
-
-	for _, filename := range keys {
-		header := &tar.Header{
-			Name:    filepath.ToSlash(filename),
-			Size:    int64(len(content[filename])),
-			ModTime: time.Time{},
-		}
-
-		if err := a.addContent(content[filename], header); err != nil {
+	for i, entry := range entries {
+		if err := a.addEntry(entry, headers[i]); err != nil {
 			return err
 		}
 	}
+
 	return nil
 }
 
@@ -217,6 +130,16 @@ func (a *TarArchiver) SetOutputFileMode(outputFileMode string) {
 	a.outputFileMode = outputFileMode
 }
 
+// SetFormat sets the TarFormat* format of the headers of the archive.
+func (a *TarArchiver) SetFormat(format string) error {
+	if _, ok := tarFormats[format]; !ok {
+		return fmt.Errorf("unsupported tar format: %s", format)
+	}
+
+	a.format = format
+	return nil
+}
+
 func (a *TarArchiver) open() error {
 	var err error
 
@@ -258,6 +181,89 @@ func (a *TarArchiver) close() {
 	}
 }
 
+// entryHeader returns the header of entry in the archive, reporting entries which the format of the archive cannot
+// represent.
+func (a *TarArchiver) entryHeader(entry ArchiveEntry) (*tar.Header, error) {
+	header := &tar.Header{
+		Name:    entry.Name,
+		Size:    int64(len(entry.Content)),
+		ModTime: time.Time{},
+		Uid:     entry.Owner.ID,
+		Uname:   entry.Owner.Name,
+		Gid:     entry.Group.ID,
+		Gname:   entry.Group.Name,
+	}
+
+	if entry.SourceInfo != nil {
+		header.Size = entry.SourceInfo.Size()
+		header.Mode = int64(entry.SourceInfo.Mode())
+	}
+
+	if !entry.ModTime.IsZero() {
+		header.ModTime = entry.ModTime
+	}
+
+	if a.outputFileMode != "" {
+		filemode, err := strconv.ParseInt(a.outputFileMode, 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing output_file_mode value: %s", a.outputFileMode)
+		}
+		header.Mode = filemode
+	}
+
+	if entry.ModeSet {
+		header.Mode = int64(entry.Mode)
+	}
+
+	for name, value := range entry.Xattrs {
+		if header.PAXRecords == nil {
+			header.PAXRecords = make(map[string]string)
+		}
+		header.PAXRecords[xattrPAXPrefix+name] = value
+	}
+
+	switch {
+	case entry.isDir():
+		header.Typeflag = tar.TypeDir
+		header.Name += "/"
+		header.Size = 0
+		header.Mode = int64(dirFileMode(entry))
+	case entry.LinkTarget != "":
+		header.Typeflag = tar.TypeSymlink
+		header.Linkname = entry.LinkTarget
+		header.Size = 0
+		header.Mode &= int64(os.ModePerm)
+	case entry.HardLinkTarget != "":
+		header.Typeflag = tar.TypeLink
+		header.Linkname = entry.HardLinkTarget
+		header.Size = 0
+	}
+
+	if a.format != "" {
+		header.Format = tarFormats[a.format]
+
+		// Check the header, so that entries which the format cannot represent are reported as such rather than as a
+		// failure to write the archive.
+		if err := tar.NewWriter(io.Discard).WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("%s cannot be archived in the %s tar format: %s", entry.Name, a.format, err)
+		}
+	}
+
+	return header, nil
+}
+
+func (a *TarArchiver) addEntry(entry ArchiveEntry, header *tar.Header) error {
+	if entry.isDir() || entry.LinkTarget != "" || entry.HardLinkTarget != "" {
+		return a.addContent(nil, header)
+	}
+
+	if entry.SourcePath != "" {
+		return a.addFile(entry.SourcePath, header)
+	}
+
+	return a.addContent(entry.Content, header)
+}
+
 func (a *TarArchiver) addFile(filePath string, header *tar.Header) error {
 	if header == nil {
 		return fmt.Errorf("tar.Header is nil")
@@ -269,14 +275,6 @@ func (a *TarArchiver) addFile(filePath string, header *tar.Header) error {
 	}
 	defer file.Close()
 
-	if a.outputFileMode != "" {
-		fileMode, err := strconv.ParseInt(a.outputFileMode, 0, 32)
-		if err != nil {
-			return fmt.Errorf("error parsing output_file_mode value: %s", a.outputFileMode)
-		}
-		header.Mode = fileMode
-	}
-
 	err = a.tarWriter.WriteHeader(header)
 	if err != nil {
 		return fmt.Errorf("could not write header for file '%s', got error '%w'", filePath, err)
@@ -295,14 +293,6 @@ func (a *TarArchiver) addContent(content []byte, header *tar.Header) error {
 		return errors.New("tar.Header is nil")
 	}
 
-	if a.outputFileMode != "" {
-		filemode, err := strconv.ParseInt(a.outputFileMode, 0, 32)
-		if err != nil {
-			return fmt.Errorf("error parsing output_file_mode value: %s", a.outputFileMode)
-		}
-		header.Mode = filemode
-	}
-
 	if err := a.tarWriter.WriteHeader(header); err != nil {
 		return fmt.Errorf("could not write header, got error '%w'", err)
 	}
@@ -10,7 +10,9 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -154,6 +156,389 @@ func TestTarArchiver_Dir_Exclude_With_Directory(t *testing.T) {
 	})
 }
 
+func TestTarArchiver_Dir_NodePruneDevDependencies(t *testing.T) {
+	tarFilePath := filepath.Join(t.TempDir(), "archive-dir-node-prune.tar.gz")
+
+	archiver := NewTarGzArchiver(tarFilePath)
+	if err := archiver.ArchiveDir(createTestNodeProject(t), ArchiveDirOpts{
+		Excludes:                 []string{"package-lock.json"},
+		NodePruneDevDependencies: true,
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureTarContents(t, tarFilePath, map[string][]byte{
+		"index.js":                            []byte("require('lodash')"),
+		"node_modules/lodash/index.js":        []byte("lodash"),
+		"node_modules/@smithy/types/index.js": []byte("smithy"),
+	})
+}
+
+func TestTarArchiver_Dirs(t *testing.T) {
+	tarFilePath := filepath.Join(t.TempDir(), "archive-dirs.tar.gz")
+
+	archiver := NewTarGzArchiver(tarFilePath)
+	if err := archiver.ArchiveDirs(createTestSourceDirectories(t)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureTarContents(t, tarFilePath, map[string][]byte{
+		"config/app.json":        []byte("{}"),
+		"handler.py":             []byte("import lib"),
+		"lib/shared/__init__.py": []byte("shared"),
+	})
+}
+
+func TestTarArchiver_Entries(t *testing.T) {
+	tarFilePath := filepath.Join(t.TempDir(), "archive-entries.tar.gz")
+	mtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	archiver := NewTarGzArchiver(tarFilePath)
+	archiver.SetOutputFileMode("0644")
+	if err := archiver.ArchiveEntries(createTestEntries(t, mtime)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureTarContents(t, tarFilePath, map[string][]byte{
+		"bin/run":      []byte("#!/bin/sh"),
+		"config.json":  []byte("{}"),
+		"lib/util.txt": []byte("util"),
+	})
+
+	f, err := os.Open(tarFilePath)
+	if err != nil {
+		t.Fatalf("could not open tar.gz file: %s", err)
+	}
+	defer f.Close()
+
+	gzf, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("could not open tar.gz file: %s", err)
+	}
+
+	tarReader := tar.NewReader(gzf)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wantMode := int64(0644)
+		if header.Name == "bin/run" {
+			wantMode = 0755
+		}
+		if header.Mode != wantMode {
+			t.Errorf("expected mode %o for %s, got %o", wantMode, header.Name, header.Mode)
+		}
+
+		if header.Name == "bin/run" && !header.ModTime.Equal(mtime) {
+			t.Errorf("expected mtime %s for %s, got %s", mtime, header.Name, header.ModTime)
+		}
+	}
+}
+
+func TestTarArchiver_Owners(t *testing.T) {
+	tarFilePath := filepath.Join(t.TempDir(), "archive-owners.tar.gz")
+
+	archiver := NewTarGzArchiver(tarFilePath)
+	err := archiver.ArchiveEntries([]ArchiveEntry{
+		{Name: "main.py", Content: []byte("main")},
+		{
+			Name:    "bin/run",
+			Content: []byte("#!/bin/sh"),
+			Owner:   ArchiveOwner{ID: 1000, Name: "app"},
+			Group:   ArchiveOwner{ID: 1000, Name: "app"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	headers := tarHeaders(t, tarFilePath)
+	if h := headers["main.py"]; h.Uid != 0 || h.Gid != 0 || h.Uname != "" || h.Gname != "" {
+		t.Errorf("expected main.py owned by 0:0 with no names, got %d:%d (%s:%s)", h.Uid, h.Gid, h.Uname, h.Gname)
+	}
+	if h := headers["bin/run"]; h.Uid != 1000 || h.Gid != 1000 || h.Uname != "app" || h.Gname != "app" {
+		t.Errorf("expected bin/run owned by app:app (1000:1000), got %d:%d (%s:%s)", h.Uid, h.Gid, h.Uname, h.Gname)
+	}
+}
+
+func TestTarArchiver_Xattrs(t *testing.T) {
+	tarFilePath := filepath.Join(t.TempDir(), "archive-xattrs.tar.gz")
+	capability := "\x01\x00\x00\x02\x00\x04\x00\x00"
+
+	archiver := NewTarGzArchiver(tarFilePath)
+	err := archiver.ArchiveEntries([]ArchiveEntry{
+		{Name: "main.py", Content: []byte("main")},
+		{
+			Name:    "bin/server",
+			Content: []byte("\x7fELF"),
+			Xattrs: map[string]string{
+				"security.capability": capability,
+				"security.selinux":    "system_u:object_r:bin_t:s0",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	headers := tarHeaders(t, tarFilePath)
+	if records := headers["main.py"].PAXRecords; len(records) != 0 {
+		t.Errorf("expected no PAX records for main.py, got %q", records)
+	}
+
+	want := map[string]string{
+		"SCHILY.xattr.security.capability": capability,
+		"SCHILY.xattr.security.selinux":    "system_u:object_r:bin_t:s0",
+	}
+	if got := headers["bin/server"].PAXRecords; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected PAX records %q for bin/server, got %q", want, got)
+	}
+}
+
+func TestTarArchiver_HardLinks(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"lib.so": "lib"})
+
+	entry, err := fileEntry(filepath.Join(dir, "lib.so"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	link := entry
+	link.Name = "lib.so.1"
+	link.HardLinkTarget = "lib.so"
+
+	tarFilePath := filepath.Join(t.TempDir(), "archive-hardlinks.tar.gz")
+	if err := NewTarGzArchiver(tarFilePath).ArchiveEntries([]ArchiveEntry{entry, link}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	headers := tarHeaders(t, tarFilePath)
+	if h := headers["lib.so"]; h.Typeflag != tar.TypeReg || h.Size != 3 {
+		t.Errorf("expected lib.so to be a file of 3 bytes, got type %c of %d bytes", h.Typeflag, h.Size)
+	}
+	if h := headers["lib.so.1"]; h.Typeflag != tar.TypeLink || h.Linkname != "lib.so" || h.Size != 0 {
+		t.Errorf("expected lib.so.1 to be a hard link to lib.so, got type %c to %q of %d bytes", h.Typeflag, h.Linkname, h.Size)
+	}
+}
+
+func TestTarArchiver_Format(t *testing.T) {
+	longName := strings.Repeat("nested/", 40) + "main.py"
+
+	for format, want := range tarFormats {
+		t.Run(format, func(t *testing.T) {
+			tarFilePath := filepath.Join(t.TempDir(), "archive-format.tar.gz")
+
+			archiver := NewTarGzArchiver(tarFilePath).(*TarArchiver)
+			if err := archiver.SetFormat(format); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			entries := []ArchiveEntry{{Name: "main.py", Content: []byte("main")}}
+			if err := archiver.ArchiveEntries(entries); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			err := archiver.ArchiveEntries(append(entries, ArchiveEntry{Name: longName, Content: []byte("long")}))
+			if format == TarFormatUSTAR {
+				if err == nil || !strings.Contains(err.Error(), "cannot be archived in the ustar tar format") {
+					t.Errorf("expected error for long path, got: %v", err)
+				}
+
+				// The archive written before is left as is, rather than replaced by the entries preceding the one
+				// which cannot be archived.
+				if err := archiver.ArchiveEntries([]ArchiveEntry{
+					{Name: "other.py", Content: []byte("other")},
+					{Name: longName, Content: []byte("long")},
+				}); err == nil {
+					t.Errorf("expected error for long path")
+				}
+				ensureTarContents(t, tarFilePath, map[string][]byte{
+					"main.py": []byte("main"),
+				})
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			ensureTarContents(t, tarFilePath, map[string][]byte{
+				"main.py": []byte("main"),
+				longName:  []byte("long"),
+			})
+
+			if got := tarHeaders(t, tarFilePath)[longName].Format; got&want == 0 {
+				t.Errorf("expected %s header for the long path, got %s", want, got)
+			}
+		})
+	}
+
+	if err := NewTarGzArchiver("archive.tar.gz").(*TarArchiver).SetFormat("v7"); err == nil {
+		t.Errorf("expected error for unsupported format")
+	}
+}
+
+func TestTarArchiver_Dir_IncludeDirectories(t *testing.T) {
+	tarFilePath := filepath.Join(t.TempDir(), "archive-dir-directories.tar.gz")
+
+	archiver := NewTarGzArchiver(tarFilePath)
+	archiver.SetOutputFileMode("0644")
+	opts := ArchiveDirOpts{Excludes: []string{"build/**"}, IncludeDirectories: true}
+	if err := archiver.ArchiveDir(createTestDirectoriesDir(t), opts); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	headers := tarHeaders(t, tarFilePath)
+	if h := headers["app/main.py"]; h == nil || h.Mode != 0644 {
+		t.Fatalf("expected app/main.py with mode 0644, got %+v", h)
+	}
+
+	// Directories get the same modification time as files.
+	mtime := headers["app/main.py"].ModTime
+	for _, name := range []string{"app/", "lib/", "logs/", "tmp/", "tmp/cache/"} {
+		h, ok := headers[name]
+		if !ok {
+			t.Errorf("expected directory %s", name)
+			continue
+		}
+		if h.Typeflag != tar.TypeDir || h.Mode != 0755 || h.Size != 0 || !h.ModTime.Equal(mtime) {
+			t.Errorf("expected directory %s with mode 0755 modified at %s, got type %c with mode %o modified at %s",
+				name, mtime, h.Typeflag, h.Mode, h.ModTime)
+		}
+	}
+}
+
+func TestTarArchiver_Dir_Includes(t *testing.T) {
+	tarFilePath := filepath.Join(t.TempDir(), "archive-dir-includes.tar.gz")
+
+	archiver := NewTarGzArchiver(tarFilePath)
+	if err := archiver.ArchiveDir(createTestIncludesDir(t), ArchiveDirOpts{
+		Includes: []string{"**/*.py", "templates/**"},
+		Excludes: []string{"tests/**"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureTarContents(t, tarFilePath, map[string][]byte{
+		"main.py":              []byte("main"),
+		"pkg/util.py":          []byte("util"),
+		"templates/index.html": []byte("index"),
+	})
+}
+
+func TestTarArchiver_Dir_ExcludesGitignore(t *testing.T) {
+	tarFilePath := filepath.Join(t.TempDir(), "archive-dir-excludes-gitignore.tar.gz")
+
+	archiver := NewTarGzArchiver(tarFilePath)
+	if err := archiver.ArchiveDir(createTestIncludesDir(t), ArchiveDirOpts{
+		Excludes:       []string{"*.py", "!main.py", "tests/", "pkg/*", "!pkg/data.json"},
+		ExcludesSyntax: ExcludesSyntaxGitignore,
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureTarContents(t, tarFilePath, map[string][]byte{
+		"main.py":              []byte("main"),
+		"README.md":            []byte("readme"),
+		"pkg/data.json":        []byte("{}"),
+		"templates/index.html": []byte("index"),
+	})
+}
+
+func TestTarArchiver_Dir_IgnoreFiles(t *testing.T) {
+	tarFilePath := filepath.Join(t.TempDir(), "archive-dir-ignore-files.tar.gz")
+
+	archiver := NewTarGzArchiver(tarFilePath)
+	if err := archiver.ArchiveDir(createTestIgnoreFilesDir(t), ArchiveDirOpts{
+		IgnoreFiles: []string{".gitignore", ".archiveignore"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureTarContents(t, tarFilePath, testIgnoreFilesContents)
+}
+
+func TestTarArchiver_Git(t *testing.T) {
+	tarFilePath := filepath.Join(t.TempDir(), "archive-git.tar.gz")
+
+	_, entries, err := gitEntries(GitSourceOpts{RepositoryPath: createTestGitRepository(t)})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	archiver := NewTarGzArchiver(tarFilePath)
+	if err := archiver.ArchiveEntries(entries); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	f, err := os.Open(tarFilePath)
+	if err != nil {
+		t.Fatalf("could not open tar.gz file: %s", err)
+	}
+	defer f.Close()
+
+	gzf, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("could not open tar.gz file: %s", err)
+	}
+	defer gzf.Close()
+
+	modes := map[string]int64{
+		"README.md":  0644,
+		"bin/run.sh": 0755,
+		"link.txt":   0777,
+	}
+
+	tarReader := tar.NewReader(gzf)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("could not read tar.gz file: %s", err)
+		}
+
+		if want, ok := modes[header.Name]; ok && header.Mode != want {
+			t.Errorf("%s: got mode %o, want %o", header.Name, header.Mode, want)
+		}
+
+		if header.Name == "link.txt" && (header.Typeflag != tar.TypeSymlink || header.Linkname != "README.md") {
+			t.Errorf("%s: expected a symbolic link to README.md, got type %c to %q", header.Name, header.Typeflag, header.Linkname)
+		}
+	}
+}
+
+func TestTarArchiver_SourceArchive(t *testing.T) {
+	tarFilePath := filepath.Join(t.TempDir(), "archive-source-archive.tar.gz")
+	zipFilePath := createTestSourceZip(t, filepath.Join(t.TempDir(), "source.zip"))
+
+	entries, err := sourceArchiveEntries(zipFilePath, SourceArchiveOpts{
+		Excludes:        []string{"latest.txt"},
+		StripComponents: 1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	archiver := NewTarGzArchiver(tarFilePath)
+	if err := archiver.ArchiveEntries(entries); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureTarContents(t, tarFilePath, map[string][]byte{
+		"README.md":   []byte("readme"),
+		"bin/run.sh":  []byte("#!/bin/sh\necho run\n"),
+		"docs/a.md":   []byte("docs"),
+		"lib/main.py": []byte("main"),
+	})
+}
+
 func TestTarArchiver_Multiple(t *testing.T) {
 	tarFilePath := filepath.Join(t.TempDir(), "archive-content.tar.gz")
 
@@ -505,3 +890,35 @@ func ensureTarFileMode(t *testing.T, tarfilepath string, outputFileMode string)
 		}
 	}
 }
+
+// tarHeaders returns the headers of the entries of a tar.gz archive by name.
+func tarHeaders(t *testing.T, tarFilePath string) map[string]*tar.Header {
+	t.Helper()
+
+	f, err := os.Open(tarFilePath)
+	if err != nil {
+		t.Fatalf("could not open tar.gz file: %s", err)
+	}
+	defer f.Close()
+
+	gzf, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("could not open tar.gz file: %s", err)
+	}
+
+	headers := make(map[string]*tar.Header)
+	tarReader := tar.NewReader(gzf)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		headers[header.Name] = header
+	}
+
+	return headers
+}
@@ -0,0 +1,58 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package archive
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	XattrNamespaceSecurity = "security"
+	XattrNamespaceSystem   = "system"
+	XattrNamespaceTrusted  = "trusted"
+	XattrNamespaceUser     = "user"
+)
+
+// defaultXattrNamespaces are the namespaces of the extended attributes archived when none are configured, which
+// include file capabilities and SELinux labels.
+var defaultXattrNamespaces = []string{XattrNamespaceSecurity, XattrNamespaceUser}
+
+// xattrPAXPrefix prefixes the names of the extended attributes recorded as PAX records, as GNU tar and bsdtar do.
+const xattrPAXPrefix = "SCHILY.xattr."
+
+// applyXattrs sets the extended attributes of the entries read from a file to those of the file in namespaces.
+// Extended attributes are only read on Linux.
+func applyXattrs(entries []ArchiveEntry, namespaces []string) error {
+	if len(namespaces) == 0 {
+		namespaces = defaultXattrNamespaces
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		if entry.SourcePath == "" || entry.LinkTarget != "" {
+			continue
+		}
+
+		xattrs, err := readXattrs(entry.SourcePath)
+		if err != nil {
+			return fmt.Errorf("error reading extended attributes of %s: %s", entry.SourcePath, err)
+		}
+
+		for name, value := range xattrs {
+			namespace, _, _ := strings.Cut(name, ".")
+			if !slices.Contains(namespaces, namespace) {
+				continue
+			}
+
+			if entry.Xattrs == nil {
+				entry.Xattrs = make(map[string]string)
+			}
+			entry.Xattrs[name] = value
+		}
+	}
+
+	return nil
+}
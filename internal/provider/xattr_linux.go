@@ -0,0 +1,65 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build linux
+
+package archive
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+)
+
+// readXattrs returns the extended attributes of the file at path by name. Files on filesystems without extended
+// attributes have none.
+func readXattrs(path string) (map[string]string, error) {
+	size, err := syscall.Listxattr(path, nil)
+	if errors.Is(err, syscall.ENOTSUP) {
+		return nil, nil
+	}
+	if err != nil || size == 0 {
+		return nil, err
+	}
+
+	list := make([]byte, size)
+	size, err = syscall.Listxattr(path, list)
+	if err != nil {
+		return nil, err
+	}
+
+	xattrs := make(map[string]string)
+	for _, name := range strings.Split(string(list[:size]), "\x00") {
+		if name == "" {
+			continue
+		}
+
+		value, err := getXattr(path, name)
+		if errors.Is(err, syscall.ENODATA) {
+			// The attribute was removed since the list was read.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		xattrs[name] = value
+	}
+
+	return xattrs, nil
+}
+
+func getXattr(path, name string) (string, error) {
+	size, err := syscall.Getxattr(path, name, nil)
+	if err != nil || size == 0 {
+		return "", err
+	}
+
+	value := make([]byte, size)
+	size, err = syscall.Getxattr(path, name, value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(value[:size]), nil
+}
@@ -0,0 +1,126 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build linux
+
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"syscall"
+	"testing"
+
+	r "github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestApplyXattrs(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"bin/server": "\x7fELF",
+		"main.py":    "main",
+	})
+
+	setTestXattr(t, filepath.Join(dir, "bin/server"), "user.mime_type", "application/x-executable")
+	setTestXattr(t, filepath.Join(dir, "bin/server"), "user.origin", "build")
+
+	entries, err := walkDir(dir, ArchiveDirOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	entries = append(entries, ArchiveEntry{Name: "config.json", Content: []byte("{}")})
+
+	if err := applyXattrs(entries, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := map[string]map[string]string{
+		"bin/server": {"user.mime_type": "application/x-executable", "user.origin": "build"},
+	}
+	for _, entry := range entries {
+		if !reflect.DeepEqual(entry.Xattrs, want[entry.Name]) {
+			t.Errorf("%s: got extended attributes %q, want %q", entry.Name, entry.Xattrs, want[entry.Name])
+		}
+	}
+
+	for i := range entries {
+		entries[i].Xattrs = nil
+	}
+
+	// Extended attributes outside of the namespaces are left out.
+	if err := applyXattrs(entries, []string{XattrNamespaceSecurity}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, entry := range entries {
+		if entry.Xattrs != nil {
+			t.Errorf("%s: got extended attributes %q, want none", entry.Name, entry.Xattrs)
+		}
+	}
+}
+
+func TestResource_PreserveXattrs(t *testing.T) {
+	td := t.TempDir()
+
+	f := filepath.Join(td, "tar_file_acc_test_preserve_xattrs.tar.gz")
+	dir := filepath.Join(td, "src")
+	writeTestFiles(t, dir, map[string]string{
+		"bin/server": "\x7fELF",
+		"main.py":    "main",
+	})
+	setTestXattr(t, filepath.Join(dir, "bin/server"), "user.origin", "build")
+
+	r.ParallelTest(t, r.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories(),
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type            = "zip"
+  source_dir      = "%s"
+  output_path     = "%s"
+  preserve_xattrs = true
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`preserve_xattrs\s+is\s+not\s+supported\s+by\s+zip\s+archives`),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "archive_file" "foo" {
+  type             = "tar.gz"
+  source_dir       = "%s"
+  output_path      = "%s"
+  preserve_xattrs  = true
+  xattr_namespaces = ["user"]
+}
+`, filepath.ToSlash(dir), filepath.ToSlash(f)),
+				Check: r.TestCheckResourceAttrWith("archive_file.foo", "output_path", func(value string) error {
+					headers := tarHeaders(t, value)
+					if got := headers["bin/server"].PAXRecords["SCHILY.xattr.user.origin"]; got != "build" {
+						return fmt.Errorf("expected user.origin extended attribute of bin/server, got %q", got)
+					}
+					if records := headers["main.py"].PAXRecords; len(records) != 0 {
+						return fmt.Errorf("expected no PAX records for main.py, got %q", records)
+					}
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+// setTestXattr sets an extended attribute of a file, skipping the test when the filesystem does not support them.
+func setTestXattr(t *testing.T, path, name, value string) {
+	t.Helper()
+
+	err := syscall.Setxattr(path, name, []byte(value), 0)
+	if errors.Is(err, syscall.ENOTSUP) {
+		t.Skipf("extended attributes are not supported: %s", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
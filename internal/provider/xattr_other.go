@@ -0,0 +1,11 @@
+// Copyright IBM Corp. 2017, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !linux
+
+package archive
+
+// readXattrs returns no extended attributes, as they are only read on Linux.
+func readXattrs(string) (map[string]string, error) {
+	return nil, nil
+}
@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"strconv"
 	"time"
 
@@ -29,60 +28,19 @@ func NewZipArchiver(filepath string) Archiver {
 }
 
 func (a *ZipArchiver) ArchiveContent(content []byte, infilename string) error {
-	if err := a.open(); err != nil {
-		return err
-	}
-	defer a.close()
-
-	f, err := a.writer.Create(filepath.ToSlash(infilename))
-	if err != nil {
-		return err
-	}
-
-	_, err = f.Write(content)
-	return err
+	return a.ArchiveEntries([]ArchiveEntry{{
+		Name:    filepath.ToSlash(infilename),
+		Content: content,
+	}})
 }
 
 func (a *ZipArchiver) ArchiveFile(infilename string) error {
-	fi, err := assertValidFile(infilename)
-	if err != nil {
-		return err
-	}
-
-	content, err := os.ReadFile(infilename)
+	entry, err := fileEntry(infilename)
 	if err != nil {
 		return err
 	}
 
-	if err := a.open(); err != nil {
-		return err
-	}
-	defer a.close()
-
-	fh, err := zip.FileInfoHeader(fi)
-	if err != nil {
-		return fmt.Errorf("error creating file header: %s", err)
-	}
-	fh.Name = filepath.ToSlash(fi.Name())
-	fh.Method = zip.Deflate
-	//nolint:staticcheck // This is required as fh.SetModTime has been deprecated since Go 1.10 and using fh.Modified alone isn't enough when using a zero value
-	fh.SetModTime(time.Time{})
-
-	if a.outputFileMode != "" {
-		filemode, err := strconv.ParseUint(a.outputFileMode, 0, 32)
-		if err != nil {
-			return fmt.Errorf("error parsing output_file_mode value: %s", a.outputFileMode)
-		}
-		fh.SetMode(os.FileMode(filemode))
-	}
-
-	f, err := a.writer.CreateHeader(fh)
-	if err != nil {
-		return fmt.Errorf("error creating file inside archive: %s", err)
-	}
-
-	_, err = f.Write(content)
-	return err
+	return a.ArchiveEntries([]ArchiveEntry{entry})
 }
 
 func checkMatch(fileName string, excludes []string) (value bool, err error) {
@@ -104,150 +62,47 @@ func checkMatch(fileName string, excludes []string) (value bool, err error) {
 }
 
 func (a *ZipArchiver) ArchiveDir(indirname string, opts ArchiveDirOpts) error {
-	err := assertValidDir(indirname)
+	entries, err := walkDir(indirname, opts)
 	if err != nil {
 		return err
 	}
 
-	// ensure exclusions are OS compatible paths
-	for i := range opts.Excludes {
-		opts.Excludes[i] = filepath.FromSlash(opts.Excludes[i])
+	if err := assertNotEmpty(entries); err != nil {
+		return err
 	}
 
-	// Determine whether an empty archive would be generated.
-	isArchiveEmpty := true
+	return a.ArchiveEntries(entries)
+}
 
-	err = filepath.Walk(indirname, a.createWalkFunc("", indirname, opts, &isArchiveEmpty, true))
+func (a *ZipArchiver) ArchiveDirs(dirs []ArchiveDirSource) error {
+	entries, err := walkDirs(dirs)
 	if err != nil {
 		return err
 	}
 
-	// Return an error if an empty archive would be generated.
-	if isArchiveEmpty {
-		return fmt.Errorf("archive has not been created as it would be empty")
-	}
-
-	if err := a.open(); err != nil {
+	if err := assertNotEmpty(entries); err != nil {
 		return err
 	}
-	defer a.close()
 
-	return filepath.Walk(indirname, a.createWalkFunc("", indirname, opts, &isArchiveEmpty, false))
+	return a.ArchiveEntries(entries)
 }
 
-func (a *ZipArchiver) createWalkFunc(basePath, indirname string, opts ArchiveDirOpts, isArchiveEmpty *bool, dryRun bool) func(path string, info os.FileInfo, err error) error {
-	return func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return fmt.Errorf("error encountered during file walk: %s", err)
-		}
-
-		relname, err := filepath.Rel(indirname, path)
-		if err != nil {
-			return fmt.Errorf("error relativizing file for archival: %s", err)
-		}
-
-		archivePath := filepath.Join(basePath, relname)
-
-		isMatch, err := checkMatch(archivePath, opts.Excludes)
-		if err != nil {
-			return fmt.Errorf("error checking excludes matches: %w", err)
-		}
-
-		if info.IsDir() {
-			if isMatch {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		if isMatch {
-			return nil
-		}
-
-		if info.Mode()&os.ModeSymlink == os.ModeSymlink {
-			realPath, err := filepath.EvalSymlinks(path)
-			if err != nil {
-				return err
-			}
-
-			realInfo, err := os.Stat(realPath)
-			if err != nil {
-				return err
-			}
-
-			if realInfo.IsDir() {
-				if !opts.ExcludeSymlinkDirectories {
-					return filepath.Walk(realPath, a.createWalkFunc(archivePath, realPath, opts, isArchiveEmpty, dryRun))
-				} else {
-					return filepath.SkipDir
-				}
-			}
-
-			info = realInfo
-		}
-
-		*isArchiveEmpty = false
-
-		if dryRun {
-			return nil
-		}
-
-		fh, err := zip.FileInfoHeader(info)
-		if err != nil {
-			return fmt.Errorf("error creating file header: %s", err)
-		}
-		fh.Name = filepath.ToSlash(archivePath)
-		fh.Method = zip.Deflate
-		// fh.Modified alone isn't enough when using a zero value
-		//nolint:staticcheck
-		fh.SetModTime(time.Time{})
-
-		if a.outputFileMode != "" {
-			filemode, err := strconv.ParseUint(a.outputFileMode, 0, 32)
-			if err != nil {
-				return fmt.Errorf("error parsing output_file_mode value: %s", a.outputFileMode)
-			}
-			fh.SetMode(os.FileMode(filemode))
-		}
-
-		f, err := a.writer.CreateHeader(fh)
-		if err != nil {
-			return fmt.Errorf("error creating file inside archive: %s", err)
-		}
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("error reading file for archival: %s", err)
-		}
-		_, err = f.Write(content)
-		return err
-	}
+func (a *ZipArchiver) ArchiveMultiple(content map[string][]byte) error {
+	return a.ArchiveEntries(contentEntries(content))
 }
 
-func (a *ZipArchiver) ArchiveMultiple(content map[string][]byte) error {
+func (a *ZipArchiver) ArchiveEntries(entries []ArchiveEntry) error {
 	if err := a.open(); err != nil {
 		return err
 	}
 	defer a.close()
 
-	// Ensure files are processed in the same order so hashes don't change
-	keys := make([]string, len(content))
-	i := 0
-	for k := range content {
-		keys[i] = k
-		i++
-	}
-	sort.Strings(keys)
-
-	for _, filename := range keys {
-		f, err := a.writer.Create(filepath.ToSlash(filename))
-		if err != nil {
-			return err
-		}
-		_, err = f.Write(content[filename])
-		if err != nil {
+	for _, entry := range entries {
+		if err := a.addEntry(entry); err != nil {
 			return err
 		}
 	}
+
 	return nil
 }
 
@@ -275,3 +130,65 @@ func (a *ZipArchiver) close() {
 		a.filewriter = nil
 	}
 }
+
+func (a *ZipArchiver) addEntry(entry ArchiveEntry) error {
+	fh := &zip.FileHeader{
+		Name:   entry.Name,
+		Method: zip.Deflate,
+	}
+
+	if entry.SourceInfo != nil {
+		var err error
+		fh, err = zip.FileInfoHeader(entry.SourceInfo)
+		if err != nil {
+			return fmt.Errorf("error creating file header: %s", err)
+		}
+		fh.Name = entry.Name
+		fh.Method = zip.Deflate
+		//nolint:staticcheck // This is required as fh.SetModTime has been deprecated since Go 1.10 and using fh.Modified alone isn't enough when using a zero value
+		fh.SetModTime(time.Time{})
+
+		if a.outputFileMode != "" && !entry.isDir() {
+			filemode, err := strconv.ParseUint(a.outputFileMode, 0, 32)
+			if err != nil {
+				return fmt.Errorf("error parsing output_file_mode value: %s", a.outputFileMode)
+			}
+			fh.SetMode(os.FileMode(filemode))
+		}
+	}
+
+	if !entry.ModTime.IsZero() {
+		// The zip writer records it both as an MS-DOS time and in the Unix extended timestamp extra field.
+		fh.Modified = entry.ModTime
+	}
+
+	if entry.ModeSet {
+		fh.SetMode(entry.Mode)
+	}
+
+	content := entry.Content
+	if entry.isDir() {
+		// Directories are named with a trailing slash, and have no content.
+		fh.Name += "/"
+		fh.SetMode(os.ModeDir | dirFileMode(entry))
+		content = nil
+	} else if entry.LinkTarget != "" {
+		// A symbolic link is stored with its target as content.
+		fh.SetMode(fh.Mode().Perm() | os.ModeSymlink)
+		content = []byte(entry.LinkTarget)
+	} else if entry.SourcePath != "" {
+		var err error
+		content, err = os.ReadFile(entry.SourcePath)
+		if err != nil {
+			return fmt.Errorf("error reading file for archival: %s", err)
+		}
+	}
+
+	f, err := a.writer.CreateHeader(fh)
+	if err != nil {
+		return fmt.Errorf("error creating file inside archive: %s", err)
+	}
+
+	_, err = f.Write(content)
+	return err
+}
@@ -6,10 +6,13 @@ package archive
 import (
 	"archive/zip"
 	"bytes"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -150,6 +153,257 @@ func TestZipArchiver_Dir_Exclude_With_Directory(t *testing.T) {
 	})
 }
 
+func TestZipArchiver_Dir_NodePruneDevDependencies(t *testing.T) {
+	zipFilePath := filepath.Join(t.TempDir(), "archive-dir-node-prune.zip")
+
+	archiver := NewZipArchiver(zipFilePath)
+	if err := archiver.ArchiveDir(createTestNodeProject(t), ArchiveDirOpts{
+		Excludes:                 []string{"package-lock.json"},
+		NodePruneDevDependencies: true,
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureContents(t, zipFilePath, map[string][]byte{
+		"index.js":                            []byte("require('lodash')"),
+		"node_modules/lodash/index.js":        []byte("lodash"),
+		"node_modules/@smithy/types/index.js": []byte("smithy"),
+	})
+}
+
+func TestZipArchiver_Dirs(t *testing.T) {
+	zipFilePath := filepath.Join(t.TempDir(), "archive-dirs.zip")
+
+	archiver := NewZipArchiver(zipFilePath)
+	if err := archiver.ArchiveDirs(createTestSourceDirectories(t)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureContents(t, zipFilePath, map[string][]byte{
+		"config/app.json":        []byte("{}"),
+		"handler.py":             []byte("import lib"),
+		"lib/shared/__init__.py": []byte("shared"),
+	})
+
+	r, err := zip.OpenReader(zipFilePath)
+	if err != nil {
+		t.Fatalf("could not open zip file: %s", err)
+	}
+	defer r.Close()
+
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	if got, want := strings.Join(names, ","), "config/app.json,handler.py,lib/shared/__init__.py"; got != want {
+		t.Errorf("mismatched file order, got %s, want %s", got, want)
+	}
+}
+
+func TestZipArchiver_Dirs_Conflict(t *testing.T) {
+	zipFilePath := filepath.Join(t.TempDir(), "archive-dirs-conflict.zip")
+
+	first, second := t.TempDir(), t.TempDir()
+	writeTestFiles(t, first, map[string]string{"config.json": "first"})
+	writeTestFiles(t, second, map[string]string{"config.json": "second"})
+
+	archiver := NewZipArchiver(zipFilePath)
+	err := archiver.ArchiveDirs([]ArchiveDirSource{{Path: first}, {Path: second}})
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("config.json (from %s and %s)", first, second)) {
+		t.Fatalf("expected conflict error, got: %v", err)
+	}
+}
+
+func TestZipArchiver_Entries(t *testing.T) {
+	zipFilePath := filepath.Join(t.TempDir(), "archive-entries.zip")
+	mtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	archiver := NewZipArchiver(zipFilePath)
+	archiver.SetOutputFileMode("0644")
+	if err := archiver.ArchiveEntries(createTestEntries(t, mtime)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureContents(t, zipFilePath, map[string][]byte{
+		"bin/run":      []byte("#!/bin/sh"),
+		"config.json":  []byte("{}"),
+		"lib/util.txt": []byte("util"),
+	})
+
+	r, err := zip.OpenReader(zipFilePath)
+	if err != nil {
+		t.Fatalf("could not open zip file: %s", err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		wantMode := os.FileMode(0644)
+		if f.Name == "bin/run" {
+			wantMode = 0755
+		}
+		if f.Mode() != wantMode {
+			t.Errorf("expected mode %s for %s, got %s", wantMode, f.Name, f.Mode())
+		}
+
+		if f.Name == "bin/run" && !f.Modified.Equal(mtime) {
+			t.Errorf("expected mtime %s for %s, got %s", mtime, f.Name, f.Modified)
+		}
+
+		// The modification time is also recorded in the Unix extended timestamp extra field (0x5455).
+		if f.Name == "bin/run" && !bytes.HasPrefix(f.Extra, []byte{0x55, 0x54, 5, 0, 1}) {
+			t.Errorf("expected extended timestamp extra field for %s, got %x", f.Name, f.Extra)
+		}
+	}
+}
+
+func TestZipArchiver_Dir_IncludeDirectories(t *testing.T) {
+	zipFilePath := filepath.Join(t.TempDir(), "archive-dir-directories.zip")
+
+	archiver := NewZipArchiver(zipFilePath)
+	archiver.SetOutputFileMode("0644")
+	opts := ArchiveDirOpts{Excludes: []string{"build/**"}, IncludeDirectories: true}
+	if err := archiver.ArchiveDir(createTestDirectoriesDir(t), opts); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureFileModes(t, zipFilePath, map[string]os.FileMode{
+		"app/":        os.ModeDir | 0755,
+		"app/main.py": 0644,
+		"logs/":       os.ModeDir | 0755,
+		"tmp/":        os.ModeDir | 0755,
+		"tmp/cache/":  os.ModeDir | 0755,
+	})
+
+	r, err := zip.OpenReader(zipFilePath)
+	if err != nil {
+		t.Fatalf("could not open zip file: %s", err)
+	}
+	defer r.Close()
+
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	want := []string{"app/", "app/main.py", "lib/", "lib/util.py", "logs/", "tmp/", "tmp/cache/"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got files %q, want %q", names, want)
+	}
+}
+
+func TestZipArchiver_Dir_Includes(t *testing.T) {
+	zipFilePath := filepath.Join(t.TempDir(), "archive-dir-includes.zip")
+
+	archiver := NewZipArchiver(zipFilePath)
+	if err := archiver.ArchiveDir(createTestIncludesDir(t), ArchiveDirOpts{
+		Includes: []string{"**/*.py", "templates/**"},
+		Excludes: []string{"tests/**"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureContents(t, zipFilePath, map[string][]byte{
+		"main.py":              []byte("main"),
+		"pkg/util.py":          []byte("util"),
+		"templates/index.html": []byte("index"),
+	})
+}
+
+func TestZipArchiver_Dir_ExcludesGitignore(t *testing.T) {
+	zipFilePath := filepath.Join(t.TempDir(), "archive-dir-excludes-gitignore.zip")
+
+	archiver := NewZipArchiver(zipFilePath)
+	if err := archiver.ArchiveDir(createTestIncludesDir(t), ArchiveDirOpts{
+		Excludes:       []string{"*.py", "!main.py", "tests/", "pkg/*", "!pkg/data.json"},
+		ExcludesSyntax: ExcludesSyntaxGitignore,
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureContents(t, zipFilePath, map[string][]byte{
+		"main.py":              []byte("main"),
+		"README.md":            []byte("readme"),
+		"pkg/data.json":        []byte("{}"),
+		"templates/index.html": []byte("index"),
+	})
+}
+
+func TestZipArchiver_Dir_IgnoreFiles(t *testing.T) {
+	zipFilePath := filepath.Join(t.TempDir(), "archive-dir-ignore-files.zip")
+
+	archiver := NewZipArchiver(zipFilePath)
+	if err := archiver.ArchiveDir(createTestIgnoreFilesDir(t), ArchiveDirOpts{
+		IgnoreFiles: []string{".gitignore", ".archiveignore"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureContents(t, zipFilePath, testIgnoreFilesContents)
+}
+
+func TestZipArchiver_Git(t *testing.T) {
+	zipFilePath := filepath.Join(t.TempDir(), "archive-git.zip")
+
+	_, entries, err := gitEntries(GitSourceOpts{RepositoryPath: createTestGitRepository(t)})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	archiver := NewZipArchiver(zipFilePath)
+	if err := archiver.ArchiveEntries(entries); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	r, err := zip.OpenReader(zipFilePath)
+	if err != nil {
+		t.Fatalf("could not open zip file: %s", err)
+	}
+	defer r.Close()
+
+	modes := map[string]os.FileMode{
+		"README.md":  0644,
+		"bin/run.sh": 0755,
+		"link.txt":   os.ModeSymlink | 0777,
+	}
+	for _, f := range r.File {
+		if want, ok := modes[f.Name]; ok && f.Mode() != want {
+			t.Errorf("%s: got mode %s, want %s", f.Name, f.Mode(), want)
+		}
+	}
+
+	ensureContents(t, zipFilePath, map[string][]byte{
+		"README.md":       []byte("readme v2"),
+		"bin/run.sh":      []byte("#!/bin/sh\necho run\n"),
+		"link.txt":        []byte("README.md"),
+		"src/main.py":     []byte(testGitLargeFile("v2")),
+		"src/pkg/util.py": []byte("util"),
+	})
+}
+
+func TestZipArchiver_SourceArchive(t *testing.T) {
+	zipFilePath := filepath.Join(t.TempDir(), "archive-source-archive.zip")
+	tarFilePath := createTestSourceTar(t, filepath.Join(t.TempDir(), "source.tar.gz"), true)
+
+	entries, err := sourceArchiveEntries(tarFilePath, SourceArchiveOpts{
+		Excludes:        []string{"docs"},
+		StripComponents: 1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	archiver := NewZipArchiver(zipFilePath)
+	if err := archiver.ArchiveEntries(entries); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ensureContents(t, zipFilePath, map[string][]byte{
+		"README.md":   []byte("readme"),
+		"bin/run.sh":  []byte("#!/bin/sh\necho run\n"),
+		"latest.txt":  []byte("README.md"),
+		"lib/main.py": []byte("main"),
+	})
+}
+
 func TestZipArchiver_Multiple(t *testing.T) {
 	zipFilePath := filepath.Join(t.TempDir(), "archive-content.zip")
 
@@ -332,6 +586,108 @@ func TestZipArchiver_Dir_Exclude_Glob_ExcludeSymlinkDirectories(t *testing.T) {
 	})
 }
 
+// createTestSourceDirectories creates three directories to be archived together, each with its own prefix and
+// excludes.
+func createTestSourceDirectories(t *testing.T) []ArchiveDirSource {
+	t.Helper()
+
+	src, shared, config := t.TempDir(), t.TempDir(), t.TempDir()
+	writeTestFiles(t, src, map[string]string{
+		"handler.py":      "import lib",
+		"tests/test_a.py": "assert True",
+	})
+	writeTestFiles(t, shared, map[string]string{
+		"shared/__init__.py": "shared",
+	})
+	writeTestFiles(t, config, map[string]string{
+		"app.json":       "{}",
+		"app.local.json": "{\"debug\": true}",
+	})
+
+	return []ArchiveDirSource{
+		{Path: src, Opts: ArchiveDirOpts{Excludes: []string{"tests/**"}}},
+		{Path: shared, Opts: ArchiveDirOpts{TargetPrefix: "lib"}},
+		{Path: config, Opts: ArchiveDirOpts{TargetPrefix: "config", Excludes: []string{"*.local.json"}}},
+	}
+}
+
+// createTestEntries returns entries mixing inline content, a single file and the files of a directory.
+func createTestEntries(t *testing.T, mtime time.Time) []ArchiveEntry {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"config.json":  "{}",
+		"lib/util.txt": "util",
+	})
+
+	file, err := fileEntry(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	libEntries, err := walkDir(filepath.Join(dir, "lib"), ArchiveDirOpts{TargetPrefix: "lib"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return append([]ArchiveEntry{
+		{Name: "bin/run", Content: []byte("#!/bin/sh"), Mode: 0755, ModeSet: true, ModTime: mtime},
+		file,
+	}, libEntries...)
+}
+
+// createTestIncludesDir creates a directory mixing files which are and are not matched by the includes used in tests.
+func createTestIncludesDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"main.py":              "main",
+		"README.md":            "readme",
+		"pkg/util.py":          "util",
+		"pkg/data.json":        "{}",
+		"templates/index.html": "index",
+		"tests/test_main.py":   "test",
+	})
+
+	return dir
+}
+
+// createTestIgnoreFilesDir creates a directory with nested ignore files.
+func createTestIgnoreFilesDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		".gitignore":           "*.log\nbuild/\n",
+		".archiveignore":       "!keep.log\n",
+		"app.py":               "app",
+		"debug.log":            "debug",
+		"keep.log":             "keep",
+		"build/out.bin":        "out",
+		"generated/root.py":    "root",
+		"src/.gitignore":       "/generated\n!debug.log\n",
+		"src/main.py":          "main",
+		"src/debug.log":        "src debug",
+		"src/generated/gen.py": "gen",
+	})
+
+	return dir
+}
+
+// testIgnoreFilesContents are the files of createTestIgnoreFilesDir which are not ignored.
+var testIgnoreFilesContents = map[string][]byte{
+	".gitignore":        []byte("*.log\nbuild/\n"),
+	".archiveignore":    []byte("!keep.log\n"),
+	"app.py":            []byte("app"),
+	"keep.log":          []byte("keep"),
+	"generated/root.py": []byte("root"),
+	"src/.gitignore":    []byte("/generated\n!debug.log\n"),
+	"src/main.py":       []byte("main"),
+	"src/debug.log":     []byte("src debug"),
+}
+
 func ensureContents(t *testing.T, zipfilepath string, wants map[string][]byte) {
 	t.Helper()
 	r, err := zip.OpenReader(zipfilepath)
@@ -397,3 +753,19 @@ func ensureFileMode(t *testing.T, zipfilepath string, outputFileMode string) {
 		}
 	}
 }
+
+// ensureFileModes checks the modes of the files of a zip archive.
+func ensureFileModes(t *testing.T, zipfilepath string, wants map[string]os.FileMode) {
+	t.Helper()
+	r, err := zip.OpenReader(zipfilepath)
+	if err != nil {
+		t.Fatalf("could not open zip file: %s", err)
+	}
+	defer r.Close()
+
+	for _, cf := range r.File {
+		if want, ok := wants[cf.Name]; ok && cf.Mode() != want {
+			t.Errorf("%s: expected filemode \"%s\" but was \"%s\"", cf.Name, want, cf.Mode())
+		}
+	}
+}